@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AbandonedApp describe application started, which exit status nobody
+// consumed (neither received from channel returned by Start, nor
+// returned by Wait or ExitCodeOrError, nor application was killed or
+// detached), before App was garbage collected.
+type AbandonedApp struct {
+	Name    string
+	Args    []string
+	Pid     int
+	Started time.Time
+	Status  ExitCodeOrError
+	// Stack is a stack trace of goroutine called Start.
+	Stack string
+}
+
+var (
+	abandonMutex   sync.RWMutex
+	abandonHandler func(AbandonedApp)
+)
+
+// SetAbandonHandler enable safety net for applications started and
+// abandoned by caller: handler is called with diagnostic, once App,
+// which exit status nobody consumed, is garbage collected, and status
+// kept for it is released. Exit status of every application is collected
+// in background anyway, so processes don't accumulate as zombies, but
+// abandoned App usually mean missed Wait or error check, which is worth
+// to report in long-running services. Handler run in background
+// goroutine, so it must not block. Applications started after the
+// call are tracked; stack of Start is recorded for them, which has
+// some cost. Nil disable safety net.
+func SetAbandonHandler(handler func(AbandonedApp)) {
+	abandonMutex.Lock()
+	defer abandonMutex.Unlock()
+	abandonHandler = handler
+}
+
+// abandonState is kept apart of App, so App can be collected.
+type abandonState struct {
+	report   AbandonedApp
+	waitCh   chan ExitCodeOrError
+	consumed atomic.Bool
+	handler  func(AbandonedApp)
+}
+
+// trackAbandon register cleanup of application started.
+func (app *App) trackAbandon() {
+	abandonMutex.RLock()
+	handler := abandonHandler
+	abandonMutex.RUnlock()
+	if handler == nil {
+		return
+	}
+	state := &abandonState{waitCh: app.waitCh, handler: handler,
+		report: AbandonedApp{Name: app.Name(), Args: app.Args(), Pid: app.Pid(),
+			Started: app.startTime, Stack: string(debug.Stack())}}
+	app.abandon = state
+	runtime.AddCleanup(app, func(s *abandonState) { s.check() }, state)
+}
+
+// consume mark exit status as consumed.
+func (app *App) consume() {
+	if app.abandon != nil {
+		app.abandon.consumed.Store(true)
+	}
+}
+
+// check report application, if exit status is still
+// waiting in channel not read by anybody.
+func (s *abandonState) check() {
+	if s.consumed.Load() {
+		return
+	}
+	select {
+	case st, ok := <-s.waitCh:
+		if !ok {
+			return
+		}
+		s.report.Status = st
+		s.handler(s.report)
+	default:
+	}
+}
@@ -0,0 +1,117 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart is the first descriptor passed by systemd protocol.
+const listenFdsStart = 3
+
+// fileListener is implemented by net.TCPListener and net.UnixListener.
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// AddListener pass listening socket (its duplicate) to the application
+// with systemd socket activation protocol: LISTEN_FDS, LISTEN_FDNAMES
+// and LISTEN_PID environments, where descriptors start from 3.
+// So supervised services can be socket-activated by the Go parent.
+// Listeners must be added before any other extra file.
+// Supported by local executor only.
+func (app *App) AddListener(name string, l net.Listener) error {
+	if len(app.cmd.ExtraFiles) != len(app.listenNames) {
+		return errors.New("listeners must be added before other extra files")
+	}
+	fl, ok := l.(fileListener)
+	if !ok {
+		return fmt.Errorf("listener %T doesn't provide file descriptor", l)
+	}
+	f, err := fl.File()
+	if err != nil {
+		return err
+	}
+	app.AddExtraFile(f)
+	app.listenNames = append(app.listenNames, name)
+	return nil
+}
+
+// Listen create listening socket and pass it to the application
+// with AddListener. Caller should close returned listener,
+// once application started, if parent doesn't need it.
+func (app *App) Listen(name, network, address string) (net.Listener, error) {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	err = app.AddListener(name, l)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// activationCmd return command wrapped with shell, which set
+// LISTEN_PID to own pid and then replace itself with the command
+// keeping the pid, since pid is not known before process start.
+func (app *App) activationCmd() (*exec.Cmd, error) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return nil, err
+	}
+	cmd := *app.cmd
+	cmd.Path = sh
+	cmd.Args = append([]string{"sh", "-c",
+		`LISTEN_PID=$$; export LISTEN_PID; exec "$@"`, "sh", app.cmd.Path},
+		app.cmd.Args[1:]...)
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env[:len(env):len(env)],
+		"LISTEN_FDS="+strconv.Itoa(len(app.listenNames)),
+		"LISTEN_FDNAMES="+strings.Join(app.listenNames, ":"))
+	return &cmd, nil
+}
+
+// ListenersFromEnv is a child side helper, which return listeners
+// passed with systemd socket activation protocol (either by systemd
+// or AddListener) and their names. Environments are unset, so they
+// are not inherited by the further children.
+func ListenersFromEnv() ([]net.Listener, []string, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	var listeners []net.Listener
+	var list []string
+	for i := 0; i < count; i++ {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		listeners = append(listeners, l)
+		list = append(list, name)
+	}
+	return listeners, list, nil
+}
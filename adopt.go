@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// adoptPoll is an interval of adopted process liveness check.
+const adoptPoll = 100 * time.Millisecond
+
+// Adopt wrap already running process (for instance, found via pid
+// file) into App, so it can be supervised as the one started by the
+// package: Signal, Kill, Terminate, Children and Wait are supported.
+// Since process is not a child of the current one, its exit is detected
+// by polling and exit status is not available: 0 is reported always.
+// Process identifier might be reused by OS once process exits.
+// Observers are notified of adopted application start and exit
+// as for the one started, see Adopted.
+func Adopt(pid int) (*App, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid process identifier %d", pid)
+	}
+	if err := signalPid(pid, 0); err != nil && err != syscall.EPERM {
+		return nil, fmt.Errorf("process %d is not running: %v", pid, err)
+	}
+	name := fmt.Sprintf("pid %d", pid)
+	if table, err := processTable(); err == nil {
+		for _, p := range table {
+			if p.Pid == pid {
+				name = p.Name
+				break
+			}
+		}
+	}
+	app := &App{cmd: &exec.Cmd{Path: name, Args: []string{name}}}
+	app.executor = LocalExecutor
+	app.startTime = time.Now()
+	app.waitCh = make(chan ExitCodeOrError, 1)
+	app.done = make(chan struct{})
+	atomic.StoreInt32(&app.launched, 1)
+	app.process = &adoptedProcess{pid: pid}
+	app.adopted = true
+	notify(func(o Observer) { o.Started(app) })
+	publish(Event{Type: EventStarted, App: app, Pid: pid})
+	app.supervise()
+	go app.asyncWait()
+	return app, nil
+}
+
+// Adopted report, whether application wrap process
+// started by someone else (see Adopt).
+func (app *App) Adopted() bool {
+	return app.adopted
+}
+
+// adoptedProcess is a handle of the process started by someone else.
+type adoptedProcess struct {
+	pid int
+}
+
+func (p *adoptedProcess) Pid() int {
+	return p.pid
+}
+
+// Wait poll process, until it has gone.
+func (p *adoptedProcess) Wait() (int, error) {
+	for {
+		if err := signalPid(p.pid, 0); err == syscall.ESRCH {
+			return 0, nil
+		}
+		time.Sleep(adoptPoll)
+	}
+}
+
+func (p *adoptedProcess) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	return signalPid(p.pid, s)
+}
+
+func (p *adoptedProcess) Kill() error {
+	return p.SignalGroup(syscall.SIGKILL)
+}
+
+// SignalGroup signal process group, if process is its leader.
+func (p *adoptedProcess) SignalGroup(sig syscall.Signal) error {
+	if pgid, err := getpgid(p.pid, nil); err == nil && pgid == p.pid {
+		return signalGroup(pgid, sig)
+	}
+	return signalPid(p.pid, sig)
+}
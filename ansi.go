@@ -0,0 +1,99 @@
+package shell
+
+import (
+	"io"
+	"sync"
+)
+
+// WithStripANSI filter color and cursor control escape sequences
+// from application output, since many tools emit them
+// even when output is not attached to terminal.
+func WithStripANSI() Option {
+	return func(app *App) {
+		app.stripANSI = true
+	}
+}
+
+func (app *App) stripANSIFilter(w io.Writer, stream string) io.Writer {
+	if !app.stripANSI {
+		return w
+	}
+	return NewANSIStripper(w)
+}
+
+// ANSI stripper states.
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+	ansiString
+	ansiStringEscape
+)
+
+// ANSIStripper remove ANSI escape sequences from the data written:
+// CSI sequences (ESC [ ... final byte), OSC, DCS and similar string
+// sequences (terminated by BEL or ESC \) and two-byte escapes.
+// Sequence split between writes is handled correctly.
+type ANSIStripper struct {
+	sync.Mutex
+	w     io.Writer
+	state int
+	buf   []byte
+}
+
+// NewANSIStripper create writer, which strip escape sequences.
+func NewANSIStripper(w io.Writer) *ANSIStripper {
+	return &ANSIStripper{w: w}
+}
+
+// Write implement io.Writer interface.
+func (s *ANSIStripper) Write(p []byte) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.buf = s.buf[:0]
+	for _, c := range p {
+		switch s.state {
+		case ansiText:
+			if c == 0x1b {
+				s.state = ansiEscape
+			} else {
+				s.buf = append(s.buf, c)
+			}
+		case ansiEscape:
+			switch c {
+			case '[':
+				s.state = ansiCSI
+			case ']', 'P', 'X', '^', '_':
+				s.state = ansiString
+			default:
+				// Two-byte sequence, like ESC c, ESC 7, ESC =.
+				s.state = ansiText
+			}
+		case ansiCSI:
+			// Parameter and intermediate bytes are in 0x20-0x3F range,
+			// final byte is in 0x40-0x7E range.
+			if c >= 0x40 && c <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiString:
+			if c == 0x07 {
+				s.state = ansiText
+			} else if c == 0x1b {
+				s.state = ansiStringEscape
+			}
+		case ansiStringEscape:
+			if c == '\\' {
+				s.state = ansiText
+			} else {
+				s.state = ansiString
+			}
+		}
+	}
+	if len(s.buf) > 0 {
+		_, err := s.w.Write(s.buf)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
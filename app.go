@@ -1,12 +1,21 @@
 package shell
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
+
+	"golang.org/x/text/encoding"
 )
 
 // ExitCodeOrError keeps exit code from application termination
@@ -16,33 +25,248 @@ type ExitCodeOrError struct {
 	Error    error
 }
 
+// Errors reported on misuse of application lifecycle.
+var (
+	// ErrNotStarted is returned by methods, which
+	// require application to be started first.
+	ErrNotStarted = errors.New("App is not started")
+	// ErrNotFinished is reported by ExitCodeOrError and Report,
+	// while application is still running.
+	ErrNotFinished = errors.New("App is not finished")
+	// ErrAlreadyWaited is reported by every Wait call, but the first one.
+	ErrAlreadyWaited = errors.New("App is already waited")
+)
+
 // App struct keep everything regarding external application started process
 // including command line, wait channel which tracks process completion
 // and exit code ether any exception happened in any stage of
 // application start up or completion.
 type App struct {
+	appConfig
 	cmd             *exec.Cmd
+	process         Process
+	startTime       time.Time
+	endTime         time.Time
+	origArgs        []string
+	cacheKeyRun     string
+	cacheStdout     *bytes.Buffer
+	cacheStderr     *bytes.Buffer
+	flightCall      *flightCall
+	flightKey       string
+	flightShared    bool
+	adopted         bool
+	tempDirRun      string
+	abandon         *abandonState
+	classSetup      []threadSetup
+	classOOM        int
+	breakerKeyRun   string
+	watchdog        *watchdog
+	broadcaster     *broadcaster
+	stamps          *stampRecorder
+	runEnv          []string
+	ioStats         *IOStats
+	ctx             context.Context
+	truncated       int32
+	tail            *lineRing
+	flushers        []flusher
+	closers         []io.Closer
+	stdinFeed       *io.PipeReader
+	stdinFeedW      *io.PipeWriter
+	stdinGate       *stdinGate
+	stdinIsFile     bool
+	stdinUngated    bool
+	closableStdin   bool
+	ptyMaster       *os.File
+	ptyRun          bool
+	respondStdin    *io.PipeReader
+	respondPipe     *io.PipeWriter
+	socketStdin     io.Reader
+	mutex           sync.Mutex
 	waitCh          chan ExitCodeOrError
+	done            chan struct{}
+	detached        int32
+	waited          int32
+	launched        int32
 	exitCodeOrError atomic.Value
 }
 
+// appConfig keep application settings, besides exec.Cmd ones,
+// which are copied by Clone.
+type appConfig struct {
+	env              []string
+	executor         Executor
+	stdoutTee        []io.Writer
+	stderrTee        []io.Writer
+	outputPrefix     string
+	stripANSI        bool
+	outputEncoding   encoding.Encoding
+	maxCapture       int64
+	truncatePolicy   TruncatePolicy
+	rateLimit        int64
+	ratePolicy       RatePolicy
+	tailSize         int
+	gzipPath         string
+	progress         []progressHandler
+	redact           []*regexp.Regexp
+	stdinData        []byte
+	stdinFile        string
+	closeStdinAfter  time.Duration
+	listenNames      []string
+	stdinFIFO        string
+	stdoutFIFO       string
+	stderrFIFO       string
+	unixSocket       string
+	unixServer       bool
+	detachedStdout   string
+	detachedStderr   string
+	pidFile          string
+	lockPath         string
+	umask            int
+	umaskSet         bool
+	threadSetup      []threadSetup
+	cmdWrappers      []cmdWrapper
+	configure        []func(cmd *exec.Cmd)
+	defaultStream    Stream
+	stdio            StdioProfile
+	respond          []respondRule
+	envProviders     []EnvProvider
+	strictArgs       bool
+	positionalArgs   []int
+	argData          interface{}
+	argTemplated     bool
+	glob             bool
+	extraPath        []string
+	cache            *Cache
+	cacheEnv         []string
+	flight           *Flight
+	breaker          *Breaker
+	heartbeatFD      bool
+	heartbeatLine    *regexp.Regexp
+	heartbeatTimeout time.Duration
+	broadcastBacklog int
+	broadcastSet     bool
+	readyTimeout     time.Duration
+	timestampLayout  string
+	ioAccounting     bool
+	execClass        ExecClass
+	gracePeriod      time.Duration
+	transcript       io.Writer
+	transcriptFormat TranscriptFormat
+	tempDir          bool
+	tempDirKeep      bool
+	stdoutFile       *fileTarget
+	stderrFile       *fileTarget
+	err              error
+}
+
 // NewApp return new application instance defined by executable name
 // and arguments, and ready to start by following Run call.
 func NewApp(name string, args ...string) *App {
 	cmd := exec.Command(name, args...)
 
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.SysProcAttr = newSysProcAttr()
 
 	app := &App{cmd: cmd}
+	app.executor = LocalExecutor
 	return app
 }
 
+// Name return base name of application executable.
+func (app *App) Name() string {
+	return filepath.Base(app.cmd.Args[0])
+}
+
+// Args return application arguments, excluding executable name.
+func (app *App) Args() []string {
+	return app.cmd.Args[1:]
+}
+
+// Clone return new application instance with the same
+// executable, arguments, environments, working directory,
+// backend and options, ready to start again, since started
+// application instance can't be reused.
+func (app *App) Clone() *App {
+	args := app.cmd.Args
+	if app.origArgs != nil {
+		args = app.origArgs
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Path = app.cmd.Path
+	cmd.Dir = app.cmd.Dir
+	if app.cmd.Env != nil {
+		cmd.Env = append([]string{}, app.cmd.Env...)
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, app.cmd.ExtraFiles...)
+	if app.cmd.SysProcAttr != nil {
+		attr := *app.cmd.SysProcAttr
+		cmd.SysProcAttr = &attr
+	}
+	clone := &App{cmd: cmd, appConfig: app.appConfig}
+	// Don't share slices, which might be appended.
+	clone.env = append([]string{}, app.env...)
+	clone.stdoutTee = append([]io.Writer{}, app.stdoutTee...)
+	clone.stderrTee = append([]io.Writer{}, app.stderrTee...)
+	clone.progress = append([]progressHandler{}, app.progress...)
+	clone.listenNames = append([]string{}, app.listenNames...)
+	clone.threadSetup = append([]threadSetup{}, app.threadSetup...)
+	clone.cmdWrappers = append([]cmdWrapper{}, app.cmdWrappers...)
+	clone.configure = append([]func(cmd *exec.Cmd){}, app.configure...)
+	clone.respond = append([]respondRule{}, app.respond...)
+	clone.envProviders = append([]EnvProvider{}, app.envProviders...)
+	clone.positionalArgs = append([]int{}, app.positionalArgs...)
+	clone.extraPath = append([]string{}, app.extraPath...)
+	clone.cacheEnv = append([]string{}, app.cacheEnv...)
+	return clone
+}
+
 // AddEnvironments add environments in the form "key=value".
 func (app *App) AddEnvironments(env []string) {
 	if app.cmd.Env == nil {
 		app.cmd.Env = os.Environ()
 	}
 	app.cmd.Env = append(app.cmd.Env, env...)
+	app.env = append(app.env, env...)
+}
+
+// SetDir change working directory of application,
+// which is the current directory of the calling process by default.
+func (app *App) SetDir(dir string) {
+	app.cmd.Dir = dir
+}
+
+// SetSysProcAttr replace OS specific attributes of application
+// process, which by default contain only Setpgid flag, used to kill
+// application with all child processes. If attr doesn't set Setpgid,
+// Kill terminate only application process itself.
+func (app *App) SetSysProcAttr(attr *syscall.SysProcAttr) {
+	app.cmd.SysProcAttr = attr
+}
+
+// SysProcAttr return OS specific attributes of application process,
+// which can be modified in place before Start.
+func (app *App) SysProcAttr() *syscall.SysProcAttr {
+	if app.cmd.SysProcAttr == nil {
+		app.cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	return app.cmd.SysProcAttr
+}
+
+// Configure register function, which is called with command
+// just before it is started, to set exec.Cmd fields not wrapped
+// by the package (WaitDelay, ExtraFiles, platform attributes and so
+// on). Command might be a copy of the application one, wrapped with
+// other command, if options like WithUmask require so. Functions are
+// kept by Clone and called in order of registration. Don't replace
+// stdio set by the package, and note that Cancel can't be used,
+// since command is not created with exec.CommandContext.
+func (app *App) Configure(fn func(cmd *exec.Cmd)) {
+	app.configure = append(app.configure, fn)
+}
+
+// SetExecutor change backend used to start application,
+// which is LocalExecutor by default.
+func (app *App) SetExecutor(executor Executor) {
+	app.executor = executor
 }
 
 // Run start application synchronously with link to the process
@@ -60,7 +284,7 @@ func (app *App) Run(stdin io.Reader, stdout, stderr io.Writer) ExitCodeOrError {
 			return ExitCodeOrError{0, err}
 		}
 	*/
-	st := app.Wait()
+	st := app.wait()
 	return st
 }
 
@@ -68,23 +292,35 @@ func (app *App) sendExitCodeOrError(exitCode int, err error) {
 	state := &ExitCodeOrError{ExitCode: exitCode, Error: err}
 	// log.Printf("Exit status: %+v", state)
 	app.exitCodeOrError.Store(state)
-	app.waitCh <- *state
+	close(app.done)
+	// Channel is buffered, so send doesn't block
+	// even if nobody ever read it.
+	if atomic.LoadInt32(&app.detached) == 0 {
+		app.waitCh <- *state
+	}
 }
 
 func (app *App) asyncWait() {
 	defer close(app.waitCh)
 
-	err := app.cmd.Wait()
-	var exitCode int
-	if err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			if stat, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				exitCode = stat.ExitStatus()
-				// reset error, since exitCode already not equal to zero
-				err = nil
-			}
-		}
+	app.collectProcIO(app.Pid())
+	exitCode, err := app.process.Wait()
+	if app.watchdog != nil && app.watchdog.stop() {
+		err = ErrHeartbeatLost
 	}
+	app.flushStreams()
+	app.storeCache(exitCode, err)
+	app.recordBreaker(exitCode, err)
+	app.endTime = time.Now()
+	app.closeAll()
+	app.removeTempDir(err != nil || exitCode != 0)
+	duration := app.endTime.Sub(app.startTime)
+	notify(func(o Observer) {
+		o.Exited(app, ExitCodeOrError{ExitCode: exitCode, Error: err}, duration)
+	})
+	publish(Event{Type: EventExited, App: app, Pid: app.Pid(),
+		ExitCode: exitCode, Error: err})
+	app.unsupervise()
 	app.sendExitCodeOrError(exitCode, err)
 }
 
@@ -92,80 +328,487 @@ func (app *App) asyncWait() {
 // return channel to wait/track exit state and status.
 // If application failed to run, error returned,
 func (app *App) Start(stdin io.Reader, stdout, stderr io.Writer) (chan ExitCodeOrError, error) {
+	app.tempDirRun = ""
+	ch, err := app.start(stdin, stdout, stderr)
+	if err != nil {
+		// Every failure to start is reported here, including
+		// rejection by policy, breaker or lock, not only
+		// failure of process start itself.
+		notify(func(o Observer) { o.Failed(app, err) })
+		publish(Event{Type: EventExited, App: app, Error: err})
+		// Applications sharing execution fail the same way.
+		app.landFlight(nil, err)
+		app.removeTempDir(false)
+	}
+	return ch, err
+}
+
+func (app *App) start(stdin io.Reader, stdout, stderr io.Writer) (chan ExitCodeOrError, error) {
+	if app.err != nil {
+		return nil, app.err
+	}
+	if err := checkShutdown(); err != nil {
+		return nil, err
+	}
+	defer starting.Done()
+	if err := app.checkContext(); err != nil {
+		return nil, err
+	}
+	stdin, stdout, stderr = app.profileStreams(stdin, stdout, stderr)
+	if err := app.renderArgs(); err != nil {
+		return nil, err
+	}
+	app.globArgs()
+	app.lookExtraPath()
+	if err := app.checkPolicy(); err != nil {
+		return nil, err
+	}
+	if err := app.checkArgs(); err != nil {
+		return nil, err
+	}
+	if app.cacheable(stdin) {
+		key, in, err := app.cacheKey(stdin)
+		if err != nil {
+			return nil, err
+		}
+		if app.cache != nil && app.replayCache(key, stdout, stderr) {
+			return app.waitCh, nil
+		}
+		if app.flight != nil && app.joinFlight(key, stdout, stderr) {
+			return app.waitCh, nil
+		}
+		stdin, app.cacheKeyRun = in, key
+	}
+	if err := app.checkBreaker(); err != nil {
+		return nil, err
+	}
+	if err := app.lockInstance(); err != nil {
+		return nil, err
+	}
+	if err := app.checkPidFile(); err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	secrets, err := app.fetchSecrets()
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	if err := app.makeTempDir(); err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	stdout, stderr, err = app.fileStreams(stdout, stderr)
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	discardStdin, discardStdout, discardStderr := stdin == Discard,
+		stdout == Discard, stderr == Discard
+	stdin, stdout, stderr = resolveStreams(stdin, stdout, stderr)
+	hasStdout, hasStderr := stdout != nil, stderr != nil
+	app.newWatchdog()
+	stdoutTee, stderrTee, err := app.runTees()
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	switch {
+	case discardStdin:
+		stdin, err = app.nullStdin()
+	case stdin == nil:
+		stdin, err = app.defaultStdin()
+	}
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	stdin = app.mergeResponses(stdin)
+	hasStdin := stdin != nil
+	// Pipes fed by the package itself reach EOF only once
+	// application exits, so exec.Cmd must not wait for them.
+	ownStdin := hasStdin && (stdin == app.socketStdin ||
+		stdin == io.Reader(app.stdinFeed) || stdin == io.Reader(app.respondStdin))
+	// Streams inherited by default are replaced silently
+	// by FIFO and Unix socket connected later.
+	stdin, stdout, stderr = app.inheritDefaults(stdin, stdout, stderr,
+		discardStdout, discardStderr)
+	stdin = app.countStdin(stdin)
 	if stdin != nil {
-		app.cmd.Stdin = stdin
+		app.cmd.Stdin, err = app.gateStdin(stdin, ownStdin)
+		if err != nil {
+			app.closeAll()
+			return nil, err
+		}
+	}
+	stdout, stderr = composeStreams(stdout, stderr, stdoutTee, stderrTee)
+	stdout = app.filterStream(stdout, "stdout")
+	stderr = app.filterStream(stderr, "stderr")
+	if app.cacheKeyRun != "" {
+		stdout = app.cacheRecorder(stdout, &app.cacheStdout)
+		stderr = app.cacheRecorder(stderr, &app.cacheStderr)
 	}
+	if len(secrets) > 0 {
+		stdout = app.redactSecrets(stdout, secrets)
+		stderr = app.redactSecrets(stderr, secrets)
+	}
+	stdout, stderr = app.countOutput(stdout, stderr)
 	if stdout != nil {
 		app.cmd.Stdout = stdout
 	}
 	if stderr != nil {
 		app.cmd.Stderr = stderr
 	}
-	err := app.cmd.Start()
+	err = app.connectFIFOs(hasStdin, hasStdout, hasStderr)
+	if err == nil {
+		err = app.connectUnixSocket(hasStdin, hasStdout)
+	}
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	publish(Event{Type: EventStarting, App: app})
+	app.startTime = time.Now()
+	cmd := app.cmd
+	if len(app.listenNames) > 0 {
+		cmd, err = app.activationCmd()
+		if err != nil {
+			app.closeAll()
+			return nil, err
+		}
+	}
+	if app.umaskSet {
+		cmd, err = app.umaskCmd(cmd)
+		if err != nil {
+			app.closeAll()
+			return nil, err
+		}
+	}
+	for _, wrap := range app.cmdWrappers {
+		cmd, err = wrap(cmd)
+		if err != nil {
+			app.closeAll()
+			return nil, err
+		}
+	}
+	cmd = app.extraPathCmd(cmd)
+	cmd = app.tempDirCmd(cmd)
+	cmd, err = app.heartbeatCmd(cmd)
+	if err == nil {
+		cmd, err = app.classCmd(cmd)
+	}
+	if err != nil {
+		app.closeAll()
+		return nil, err
+	}
+	env := app.env
+	if len(secrets) > 0 {
+		// Inject secrets into the copy, so they are never kept in App.
+		cmd, env = injectSecrets(cmd, env, secrets)
+	}
+	for _, fn := range app.configure {
+		fn(cmd)
+	}
+	app.snapshotEnv(cmd, env, secrets)
+	process, err := app.startProcess(cmd, env)
 	if err != nil {
+		app.recordBreaker(0, err)
+		app.flushStreams()
+		app.closeAll()
 		return nil, err
 	}
-	app.waitCh = make(chan ExitCodeOrError)
+	if pid := process.Pid(); pid != 0 {
+		err = app.writePidFile(pid, true)
+		if err == nil {
+			err = app.classStarted(pid)
+		}
+		if err != nil {
+			process.Kill()
+			process.Wait()
+			app.flushStreams()
+			app.closeAll()
+			return nil, err
+		}
+	}
+	app.waitCh = make(chan ExitCodeOrError, 1)
+	app.done = make(chan struct{})
+	atomic.StoreInt32(&app.launched, 1)
+	app.mutex.Lock()
+	app.process = process
+	app.mutex.Unlock()
+	if app.watchdog != nil {
+		app.watchdog.start()
+	}
+	if app.closeStdinAfter > 0 {
+		time.AfterFunc(app.closeStdinAfter, func() { app.CloseStdin() })
+	}
+	notify(func(o Observer) { o.Started(app) })
+	publish(Event{Type: EventStarted, App: app, Pid: app.Pid()})
+	app.trackAbandon()
+	app.supervise()
 	go app.asyncWait()
+	app.watchContext()
 	return app.waitCh, nil
 }
 
-// CheckIsInstalled use Linux/FreeBSD utility [which] to find
-// if app is installed or not in the system.
-func (app *App) CheckIsInstalled() error {
-	// Won't use [whereis], because it doesn't return correct exit code
-	// based on search results. Can use [type], as an option.
-	whApp := NewApp("which", app.cmd.Path)
-	st := whApp.Run(nil, nil, nil)
-	if st.Error != nil {
-		return st.Error
+// ExitCodeOrError return exit status once application has been finished,
+// otherwise status with ErrNotStarted or ErrNotFinished error.
+func (app *App) ExitCodeOrError() *ExitCodeOrError {
+	ref, ok := app.exitCodeOrError.Load().(*ExitCodeOrError)
+	if !ok {
+		if !app.isStarted() {
+			return &ExitCodeOrError{Error: ErrNotStarted}
+		}
+		return &ExitCodeOrError{Error: ErrNotFinished}
+	}
+	app.consume()
+	return ref
+}
+
+// Wait switch from asynchronous mode to synchronous
+// and wait until application is finished. Can be called
+// concurrently with reading channel returned by Start.
+// Only the first call return exit status, subsequent ones
+// return exit code with ErrAlreadyWaited error, so status
+// collected twice by mistake doesn't pass unnoticed.
+// ErrNotStarted is returned, if application is not started.
+func (app *App) Wait() ExitCodeOrError {
+	if !app.isStarted() {
+		return ExitCodeOrError{Error: ErrNotStarted}
 	}
-	if st.ExitCode != 0 {
-		return fmt.Errorf("App \"%s\" does not exist", app.cmd.Path)
+	st := app.wait()
+	if atomic.AddInt32(&app.waited, 1) > 1 {
+		return ExitCodeOrError{ExitCode: st.ExitCode, Error: ErrAlreadyWaited}
 	}
-	return nil
+	return st
 }
 
-// ExitCodeOrError return exit status once application has been finished.
-func (app *App) ExitCodeOrError() *ExitCodeOrError {
-	ref := app.exitCodeOrError.Load()
-	return ref.(*ExitCodeOrError)
+// Done return channel, which is closed once application is finished,
+// either nil, if application is not started.
+func (app *App) Done() <-chan struct{} {
+	return app.done
 }
 
-// Wait switch from asynchronous mode to synchronous
-// and wait until application is finished.
-func (app *App) Wait() ExitCodeOrError {
-	st, ok := <-app.waitCh
-	if ok {
-		return st
-	} else {
-		return ExitCodeOrError{ExitCode: 0, Error: fmt.Errorf("Exited already")}
+// isStarted report, whether application has been started, including
+// result replayed from cache or shared execution joined (see
+// WithCache and WithSingleflight), which has no process.
+func (app *App) isStarted() bool {
+	return atomic.LoadInt32(&app.launched) != 0
+}
+
+// wait block until application is finished without Wait accounting.
+func (app *App) wait() ExitCodeOrError {
+	if app.done == nil {
+		return ExitCodeOrError{Error: ErrNotStarted}
 	}
+	<-app.done
+	return *app.ExitCodeOrError()
+}
+
+// Detach declare, that caller won't wait for application started:
+// channel returned by Start is closed without exit status once
+// application exits. It's not required to call Detach for that
+// (exit status is collected in background anyway), but it documents
+// intention and release status nobody would read. Wait still work.
+func (app *App) Detach() {
+	atomic.StoreInt32(&app.detached, 1)
+	app.consume()
 }
 
 // Kill terminate application started asynchronously.
+// Descendants, which left application process group, are killed
+// as well, SurvivorsError returned, if some of them keep running.
 func (app *App) Kill() error {
 	//log.Println(fmt.Sprintf("Start killing app: %v", app.cmd))
-	if IsLinuxMacOSFreeBSD() {
-		// Kill not only main but all child processes,
-		// so extract for this purpose group id.
-		pgid, err := syscall.Getpgid(app.cmd.Process.Pid)
-		if err != nil {
-			return err
-		}
-		// Specifying gid with negative sign also results in the killing of child processes.
-		err = syscall.Kill(-pgid, syscall.SIGKILL)
-		if err != nil {
-			return err
-		}
+	if app.getProcess() == nil {
+		return ErrNotStarted
+	}
+	snapshot := app.descendants()
+	err := app.kill()
+	if err != nil {
+		return err
+	}
+	state := app.wait()
+	//log.Println(fmt.Sprintf("Done killing app: %v", app.cmd))
+	if err := killSurvivors(snapshot); err != nil {
+		return err
+	}
+	return state.Error
+}
+
+// Terminate ask application to exit sending SIGTERM to its process
+// group and kill it, if application is still running after grace
+// period. Descendants, which are running after that, are killed
+// the same way as Kill does. On Windows CTRL_BREAK_EVENT is sent
+// to the process group instead, falling back to TerminateProcess
+// at once, if event can't be delivered (for instance, application
+// started with WithoutProcessGroup, or has no console).
+func (app *App) Terminate(grace time.Duration) error {
+	return app.terminate(grace, nil, nil)
+}
+
+// terminate implement Terminate, killing application at once, when
+// abort is closed; forced is called, if application has to be killed.
+func (app *App) terminate(grace time.Duration, abort <-chan struct{}, forced func()) error {
+	process := app.getProcess()
+	if process == nil {
+		return ErrNotStarted
+	}
+	snapshot := app.descendants()
+	var err error
+	if gs, ok := process.(groupSignaler); ok {
+		err = gs.SignalGroup(syscall.SIGTERM)
 	} else {
-		// Kill only mother process
-		err := app.cmd.Process.Kill()
-		if err != nil {
-			return err
+		err = process.Signal(syscall.SIGTERM)
+	}
+	if err != nil && runtime.GOOS == "windows" {
+		// No grace period, since application is not aware of termination.
+		grace = 0
+	} else if err != nil {
+		select {
+		case <-app.done:
+			// Exited already, nothing to terminate.
+			return nil
+		default:
 		}
+		return err
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-app.done:
+	case <-timer.C:
+		err = app.forceKill(forced)
+	case <-abort:
+		err = app.forceKill(forced)
+	}
+	if err != nil {
+		return err
+	}
+	state := app.wait()
+	if err := killSurvivors(snapshot); err != nil {
+		return err
+	}
+	return state.Error
+}
+
+// forceKill kill application, which failed to exit in time.
+func (app *App) forceKill(forced func()) error {
+	if forced != nil {
+		forced()
+	}
+	return app.kill()
+}
+
+// kill terminate application without waiting for exit.
+func (app *App) kill() error {
+	process := app.getProcess()
+	if process == nil {
+		return ErrNotStarted
+	}
+	err := process.Kill()
+	if err != nil {
+		return err
+	}
+	notify(func(o Observer) { o.Killed(app) })
+	publish(Event{Type: EventKilled, App: app, Pid: app.Pid(),
+		Signal: syscall.SIGKILL})
+	return nil
+}
+
+// KillWith terminate application with signal sig instead of SIGKILL,
+// for instance, SIGQUIT to get stack dump of Go or Java application.
+// Signal is delivered to the whole process group, as Kill does,
+// if backend supports this, and to the application process otherwise.
+// Block, until application exits, so if application doesn't
+// exit on signal, call doesn't return till Kill is called, see also
+// Terminate. Descendants left running are killed, as Kill does.
+func (app *App) KillWith(sig syscall.Signal) error {
+	process := app.getProcess()
+	if process == nil {
+		return ErrNotStarted
+	}
+	snapshot := app.descendants()
+	var err error
+	if gs, ok := process.(groupSignaler); ok {
+		err = gs.SignalGroup(sig)
+	} else {
+		err = process.Signal(sig)
+	}
+	if err != nil {
+		return err
+	}
+	notify(func(o Observer) { o.Killed(app) })
+	publish(Event{Type: EventKilled, App: app, Pid: app.Pid(), Signal: sig})
+	state := app.wait()
+	if err := killSurvivors(snapshot); err != nil {
+		return err
 	}
-	state := app.Wait()
-	//log.Println(fmt.Sprintf("Done killing app: %v", app.cmd))
 	return state.Error
 }
+
+// Pid return process identifier of started application,
+// or 0 if application is not started or backend can't provide it.
+func (app *App) Pid() int {
+	process := app.getProcess()
+	if process == nil {
+		return 0
+	}
+	return process.Pid()
+}
+
+// getProcess return process handle (nil, if not started yet)
+// in the way safe for output routines, which might run
+// before Start has finished.
+func (app *App) getProcess() Process {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	return app.process
+}
+
+// ExitSignal return signal which terminated application,
+// or nil if application exited normally or backend can't report it.
+func (app *App) ExitSignal() os.Signal {
+	if p, ok := app.getProcess().(signaledProcess); ok {
+		return p.ExitSignal()
+	}
+	return nil
+}
+
+// Signal send signal to application started asynchronously.
+func (app *App) Signal(sig os.Signal) error {
+	process := app.getProcess()
+	if process == nil {
+		return ErrNotStarted
+	}
+	return process.Signal(sig)
+}
+
+// Pgid return process group identifier of application, which is
+// equal to its pid, unless application is started in the group
+// of the calling process (see WithoutProcessGroup).
+// Only LocalExecutor is supported.
+func (app *App) Pgid() (int, error) {
+	if app.executor != LocalExecutor {
+		return 0, errors.New("process group is available for local executor only")
+	}
+	pid := app.Pid()
+	if pid == 0 {
+		return 0, ErrNotStarted
+	}
+	return getpgid(pid, app.cmd.SysProcAttr)
+}
+
+// SignalGroup send signal to all processes of application process
+// group, which include its children (unless they moved to another group).
+// Fail, if application is not a leader of its own process group.
+func (app *App) SignalGroup(sig syscall.Signal) error {
+	pgid, err := app.Pgid()
+	if err != nil {
+		return err
+	}
+	if pgid != app.Pid() {
+		return errors.New("App is not a process group leader")
+	}
+	return signalGroup(pgid, sig)
+}
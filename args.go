@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ArgsCheck describe validation done by ValidateArgs.
+type ArgsCheck struct {
+	// Positional list indexes of arguments expected to be positional
+	// (file names, values), which must not start with dash, so can't
+	// be taken for option; negative index count from the end,
+	// so -1 is the last argument. Arguments following "--" are
+	// positional for sure, so not checked.
+	Positional []int
+	// Shell reject newlines and shell metacharacters, for arguments
+	// which later pass through shell (remote command line and so on).
+	Shell bool
+}
+
+// ArgsError is returned by ValidateArgs for unsafe argument.
+type ArgsError struct {
+	Index  int
+	Arg    string
+	Reason string
+}
+
+func (e *ArgsError) Error() string {
+	return fmt.Sprintf("unsafe argument #%d %q: %s", e.Index, e.Arg, e.Reason)
+}
+
+// shellMetachars are rejected by ArgsCheck.Shell.
+const shellMetachars = "\n\r;&|$`<>\\"
+
+// ValidateArgs check arguments (program name excluded) for the patterns
+// exploited by injection: NUL byte (silently truncate argument
+// passed to the process), leading dash in positional arguments and,
+// if check.Shell is set, newlines and shell metacharacters.
+func ValidateArgs(args []string, check ArgsCheck) error {
+	positional := make(map[int]bool)
+	for _, i := range check.Positional {
+		if i < 0 {
+			i += len(args)
+		}
+		positional[i] = true
+	}
+	afterDashes := false
+	for i, arg := range args {
+		if strings.IndexByte(arg, 0) != -1 {
+			return &ArgsError{Index: i, Arg: arg, Reason: "contain NUL byte"}
+		}
+		if check.Shell && strings.ContainsAny(arg, shellMetachars) {
+			return &ArgsError{Index: i, Arg: arg, Reason: "contain shell metacharacter"}
+		}
+		if positional[i] && !afterDashes && strings.HasPrefix(arg, "-") {
+			return &ArgsError{Index: i, Arg: arg, Reason: "positional argument start with dash"}
+		}
+		if arg == "--" {
+			afterDashes = true
+		}
+	}
+	return nil
+}
+
+// WithStrictArgs make Start to validate application arguments
+// with ValidateArgs, where arguments at indexes specified are positional;
+// shell checks are done for remote executors (which compose command
+// line interpreted by shell) and applications, which are shell themselves.
+func WithStrictArgs(positional ...int) Option {
+	return func(app *App) {
+		app.strictArgs = true
+		app.positionalArgs = append(app.positionalArgs, positional...)
+	}
+}
+
+// shellNames are recognized as shell by WithStrictArgs.
+var shellNames = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true,
+	"ash": true, "csh": true, "tcsh": true, "fish": true,
+}
+
+// checkArgs validate arguments, if WithStrictArgs is set.
+func (app *App) checkArgs() error {
+	if !app.strictArgs {
+		return nil
+	}
+	check := ArgsCheck{Positional: app.positionalArgs,
+		Shell: app.executor != LocalExecutor ||
+			shellNames[filepath.Base(app.cmd.Args[0])]}
+	return ValidateArgs(app.cmd.Args[1:], check)
+}
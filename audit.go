@@ -0,0 +1,175 @@
+package shell
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditRecord describe single execution registered by AuditLog.
+// Records are chained: Hash is SHA-256 of the previous record hash
+// and the record itself (with Hash empty), so modification,
+// removal or reordering of records break the chain.
+// Adopted is true for process wrapped with Adopt.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Path     string    `json:"path"`
+	Args     []string  `json:"args"`
+	Dir      string    `json:"dir"`
+	Pid      int       `json:"pid,omitempty"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+	Adopted  bool      `json:"adopted,omitempty"`
+	Prev     string    `json:"prev"`
+	Hash     string    `json:"hash"`
+}
+
+// hash compute record hash chained with previous one.
+func (r *AuditRecord) hash() string {
+	c := *r
+	c.Hash = ""
+	b, _ := json.Marshal(&c)
+	sum := sha256.Sum256(append([]byte(c.Prev), b...))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog append record of every application, which has been
+// started (or failed to start) by the package, to the sink
+// in JSON lines format. Arguments are redacted as configured
+// with WithRedact. AuditLog register itself as Observer,
+// so applications started detached are not audited.
+type AuditLog struct {
+	mutex sync.Mutex
+	sink  io.Writer
+	prev  string
+	user  string
+	err   error
+}
+
+// NewAuditLog create audit log writing to sink, where chain start
+// from prev hash (empty for the new log, see LastAuditHash).
+func NewAuditLog(sink io.Writer, prev string) *AuditLog {
+	l := &AuditLog{sink: sink, prev: prev, user: currentUser()}
+	AddObserver(l)
+	return l
+}
+
+// OpenAuditLog open (or create) audit file at path
+// and continue its chain. Call Close, once log not needed anymore.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := VerifyAuditLog(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return NewAuditLog(file, prev), nil
+}
+
+// Close unregister audit log from observers
+// and close sink, if it is io.Closer.
+func (l *AuditLog) Close() error {
+	RemoveObserver(l)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if c, ok := l.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Err return the first error happened writing to sink.
+func (l *AuditLog) Err() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.err
+}
+
+func (l *AuditLog) record(app *App, st ExitCodeOrError) {
+	path := app.resolvedPath()
+	args := make([]string, 0, len(app.cmd.Args)-1)
+	for _, arg := range app.cmd.Args[1:] {
+		args = append(args, app.Redact(arg))
+	}
+	dir := app.cmd.Dir
+	if dir == "" && app.executor == LocalExecutor {
+		dir, _ = os.Getwd()
+	}
+	r := &AuditRecord{Time: time.Now().UTC(), Path: path, Args: args,
+		Dir: dir, Pid: app.Pid(), ExitCode: st.ExitCode, Adopted: app.adopted}
+	if st.Error != nil {
+		r.Error = app.Redact(st.Error.Error())
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	r.User, r.Prev = l.user, l.prev
+	r.Hash = r.hash()
+	b, _ := json.Marshal(r)
+	_, err := l.sink.Write(append(b, '\n'))
+	if err != nil {
+		if l.err == nil {
+			l.err = err
+		}
+		return
+	}
+	l.prev = r.Hash
+}
+
+// Started implement Observer interface.
+func (l *AuditLog) Started(app *App) {
+}
+
+// Failed implement Observer interface.
+func (l *AuditLog) Failed(app *App, err error) {
+	l.record(app, ExitCodeOrError{Error: err})
+}
+
+// Exited implement Observer interface.
+func (l *AuditLog) Exited(app *App, st ExitCodeOrError, duration time.Duration) {
+	l.record(app, st)
+}
+
+// Killed implement Observer interface.
+func (l *AuditLog) Killed(app *App) {
+}
+
+// VerifyAuditLog read audit records, verifying the chain,
+// and return hash of the last record (empty for no records),
+// which continue the chain.
+func VerifyAuditLog(r io.Reader) (string, error) {
+	var prev string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var rec AuditRecord
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return "", fmt.Errorf("audit record %d is malformed: %v", n, err)
+		}
+		if rec.Prev != prev || rec.hash() != rec.Hash {
+			return "", fmt.Errorf("audit chain is broken at record %d", n)
+		}
+		prev = rec.Hash
+	}
+	return prev, scanner.Err()
+}
+
+// currentUser return name of the current user, either uid.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(os.Getuid())
+}
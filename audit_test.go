@@ -0,0 +1,73 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewAuditLog(&buf, "")
+	NewApp("true").Run(nil, nil, nil)
+	NewApp("sh", "-c", "exit 5").Run(nil, nil, nil)
+	l.Close()
+	if err := l.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of records %d", len(lines))
+	}
+	var rec AuditRecord
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if rec.ExitCode != 5 || rec.Pid == 0 {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	last, err := VerifyAuditLog(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("VerifyAuditLog: %v", err)
+	}
+	if last != rec.Hash {
+		t.Fatalf("last hash %q, expected %q", last, rec.Hash)
+	}
+}
+
+func TestAuditLogTampered(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewAuditLog(&buf, "")
+	NewApp("sh", "-c", "exit 1").Run(nil, nil, nil)
+	NewApp("true").Run(nil, nil, nil)
+	l.Close()
+	data := strings.Replace(buf.String(), `"exitCode":1`, `"exitCode":0`, 1)
+	if _, err := VerifyAuditLog(strings.NewReader(data)); err == nil {
+		t.Fatal("modified record is not detected")
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	if _, err := VerifyAuditLog(strings.NewReader(lines[1])); err == nil {
+		t.Fatal("removed record is not detected")
+	}
+}
+
+func TestAuditLogPolicyRejected(t *testing.T) {
+	SetPolicy(PolicyFunc(func(path string, args []string) error {
+		return errors.New("denied")
+	}))
+	defer SetPolicy(nil)
+	var buf bytes.Buffer
+	l := NewAuditLog(&buf, "")
+	st := NewApp("true").Run(nil, nil, nil)
+	l.Close()
+	if st.Error == nil {
+		t.Fatal("policy doesn't reject command")
+	}
+	if !strings.Contains(buf.String(), "denied") {
+		t.Fatalf("rejected start is not audited: %q", buf.String())
+	}
+}
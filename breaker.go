@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Start of application configured
+// with WithBreaker, while circuit of its command is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Breaker is a circuit breaker, which after the number of consecutive
+// failures (failed start, error or non-zero exit code) of the same
+// command (resolved path and arguments) fail fast with ErrCircuitOpen
+// for cool-down period, instead of spawning process. Once period
+// expires, single trial run is allowed: its success close circuit,
+// failure open it for another period. Breaker is safe for concurrent
+// use and can be shared by applications.
+type Breaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	classify  Classifier
+	circuits  map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	trial     time.Time
+}
+
+// NewBreaker create breaker, which open circuit after
+// failures in a row for cooldown period.
+func NewBreaker(failures int, cooldown time.Duration) *Breaker {
+	if failures < 1 {
+		failures = 1
+	}
+	return &Breaker{threshold: failures, cooldown: cooldown,
+		circuits: make(map[string]*circuit)}
+}
+
+// WithBreaker protect application with circuit breaker.
+func WithBreaker(b *Breaker) Option {
+	return func(app *App) {
+		app.breaker = b
+	}
+}
+
+// SetClassifier make breaker to count only transient failures
+// according to classifier (for instance, CurlClassifier), so
+// permanent ones, caused by the caller rather than by unavailable
+// service, don't open circuit and are treated as success.
+func (b *Breaker) SetClassifier(c Classifier) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.classify = c
+}
+
+// Reset close all circuits.
+func (b *Breaker) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.circuits = make(map[string]*circuit)
+}
+
+// allow report, whether command may be started.
+func (b *Breaker) allow(key string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c := b.circuits[key]
+	if c == nil || c.failures < b.threshold {
+		return nil
+	}
+	now := time.Now()
+	// Trial, which never reported result (Start failed
+	// before process spawned), expire with cool-down period.
+	if now.Before(c.openUntil) || now.Before(c.trial.Add(b.cooldown)) {
+		return ErrCircuitOpen
+	}
+	// Half-open: let single trial run through.
+	c.trial = now
+	return nil
+}
+
+// record register result of the run.
+func (b *Breaker) record(key string, st ExitCodeOrError) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	failed := st.Error != nil || st.ExitCode != 0
+	if b.classify != nil {
+		failed = b.classify(st) == ExitTransient
+	}
+	if !failed {
+		delete(b.circuits, key)
+		return
+	}
+	c := b.circuits[key]
+	if c == nil {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	c.failures++
+	c.trial = time.Time{}
+	if c.failures >= b.threshold {
+		c.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// breakerKey identify command guarded by breaker.
+func (app *App) breakerKey() string {
+	return app.resolvedPath() + "\x00" + strings.Join(app.cmd.Args[1:], "\x00")
+}
+
+// checkBreaker fail fast, if circuit is open.
+func (app *App) checkBreaker() error {
+	if app.breaker == nil {
+		return nil
+	}
+	app.breakerKeyRun = app.breakerKey()
+	return app.breaker.allow(app.breakerKeyRun)
+}
+
+// recordBreaker register result of the run, if guarded by breaker.
+func (app *App) recordBreaker(exitCode int, err error) {
+	if app.breakerKeyRun != "" {
+		app.breaker.record(app.breakerKeyRun, ExitCodeOrError{ExitCode: exitCode, Error: err})
+	}
+}
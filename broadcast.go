@@ -0,0 +1,202 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// broadcastQueue limit chunks queued for slow subscriber,
+// which skip further output till it catch up.
+const broadcastQueue = 1024
+
+// WithBroadcast make application output available to consumers
+// attaching to running application with Attach, keeping last
+// backlog bytes of every stream to replay for latecomers.
+// Application started by StartPTY has its terminal output
+// broadcast as stdout, so master side mustn't be read by caller.
+func WithBroadcast(backlog int) Option {
+	return func(app *App) {
+		app.broadcastBacklog = backlog
+		app.broadcastSet = true
+	}
+}
+
+// Attach connect writers to the output of running application
+// configured with WithBroadcast: backlog kept is written first, then
+// output as it arrives, till detach is called or application exits.
+// Any number of consumers can be attached simultaneously; writers are
+// called from separate goroutine, so slow consumer doesn't block
+// application, but miss output once its queue overflow.
+// Either writer can be nil to skip the stream.
+func (app *App) Attach(stdout, stderr io.Writer) (detach func(), err error) {
+	app.mutex.Lock()
+	b := app.broadcaster
+	app.mutex.Unlock()
+	if b == nil {
+		return nil, errors.New("App is not started with broadcast")
+	}
+	detach, _ = b.attach(stdout, stderr)
+	return detach, nil
+}
+
+func (app *App) broadcastWriters() (io.Writer, io.Writer, error) {
+	if !app.broadcastSet {
+		return nil, nil, nil
+	}
+	b := &broadcaster{backlog: app.broadcastBacklog,
+		subs: make(map[*subscriber]bool)}
+	app.mutex.Lock()
+	app.broadcaster = b
+	app.mutex.Unlock()
+	// Subscribers are released, once application exits.
+	app.closers = append(app.closers, b)
+	if app.ptyRun {
+		// Output is read from pseudo-terminal master side instead,
+		// since application stdio must stay connected to terminal.
+		c := &ptyCopier{done: make(chan struct{})}
+		go c.run(app.ptyMaster, &broadcastStream{b: b})
+		app.flushers = append(app.flushers, c)
+		return nil, nil, nil
+	}
+	return &broadcastStream{b: b}, &broadcastStream{b: b, stderr: true}, nil
+}
+
+// ptyCopyGrace is a time to wait for pseudo-terminal output to be read
+// once application exits, since terminal might be held open by children.
+const ptyCopyGrace = 100 * time.Millisecond
+
+// ptyCopier copy pseudo-terminal output, till all its
+// slave side descriptors are closed.
+type ptyCopier struct {
+	done chan struct{}
+}
+
+func (c *ptyCopier) run(master *os.File, w io.Writer) {
+	defer close(c.done)
+	io.Copy(w, master)
+}
+
+// Flush wait for output left in terminal to be delivered.
+func (c *ptyCopier) Flush() error {
+	select {
+	case <-c.done:
+	case <-time.After(ptyCopyGrace):
+	}
+	return nil
+}
+
+type chunk struct {
+	stderr bool
+	data   []byte
+}
+
+type subscriber struct {
+	queue chan chunk
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// broadcaster fan out output to subscribers.
+type broadcaster struct {
+	sync.Mutex
+	backlog int
+	out     []byte
+	err     []byte
+	subs    map[*subscriber]bool
+	closed  bool
+}
+
+// attach subscribe writers to the output, returning channel
+// closed, once writing finished (detached or output is over).
+func (b *broadcaster) attach(stdout, stderr io.Writer) (func(), <-chan struct{}) {
+	s := &subscriber{queue: make(chan chunk, broadcastQueue),
+		stop: make(chan struct{}), done: make(chan struct{})}
+	b.Lock()
+	if len(b.out) > 0 {
+		s.queue <- chunk{data: append([]byte{}, b.out...)}
+	}
+	if len(b.err) > 0 {
+		s.queue <- chunk{stderr: true, data: append([]byte{}, b.err...)}
+	}
+	if b.closed {
+		close(s.queue)
+	} else {
+		b.subs[s] = true
+	}
+	b.Unlock()
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case c, ok := <-s.queue:
+				if !ok {
+					return
+				}
+				w := stdout
+				if c.stderr {
+					w = stderr
+				}
+				if w != nil {
+					w.Write(c.data)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		s.once.Do(func() { close(s.stop) })
+		b.Lock()
+		delete(b.subs, s)
+		b.Unlock()
+	}, s.done
+}
+
+func (b *broadcaster) write(p []byte, stderr bool) {
+	b.Lock()
+	defer b.Unlock()
+	if b.backlog > 0 {
+		buf := &b.out
+		if stderr {
+			buf = &b.err
+		}
+		*buf = append(*buf, p...)
+		if len(*buf) > b.backlog {
+			*buf = append((*buf)[:0], (*buf)[len(*buf)-b.backlog:]...)
+		}
+	}
+	c := chunk{stderr: stderr, data: append([]byte{}, p...)}
+	for s := range b.subs {
+		select {
+		case s.queue <- c:
+		default:
+			// Subscriber is too slow, skip chunk.
+		}
+	}
+}
+
+// Close finish subscribers, once they write queued output.
+func (b *broadcaster) Close() error {
+	b.Lock()
+	defer b.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		close(s.queue)
+	}
+	b.subs = nil
+	return nil
+}
+
+type broadcastStream struct {
+	b      *broadcaster
+	stderr bool
+}
+
+func (w *broadcastStream) Write(p []byte) (int, error) {
+	w.b.write(p, w.stderr)
+	return len(p), nil
+}
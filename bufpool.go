@@ -0,0 +1,36 @@
+package shell
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is a capacity of buffer, which is not
+// returned to the pool, to not retain memory of large outputs.
+const maxPooledBuffer = 1 << 20
+
+// bufferPooling is switched off by benchmarks only,
+// to measure allocations saved by the pool.
+var bufferPooling = true
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer return empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	if !bufferPooling {
+		return new(bytes.Buffer)
+	}
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer return buffer to the pool, buffer
+// content must not be referenced anymore.
+func putBuffer(b *bytes.Buffer) {
+	if !bufferPooling || b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
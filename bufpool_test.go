@@ -0,0 +1,48 @@
+//go:build !windows
+
+package shell
+
+import "testing"
+
+func benchmarkRunLines(b *testing.B, pooling bool) {
+	defer func(prev bool) { bufferPooling = prev }(bufferPooling)
+	bufferPooling = pooling
+	app := NewApp("seq", "1", "10000")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lines, st := app.Clone().RunLines(nil)
+		if st.Error != nil || len(lines) != 10000 {
+			b.Fatalf("unexpected result: %d lines, %+v", len(lines), st)
+		}
+	}
+}
+
+func BenchmarkRunLinesPooled(b *testing.B) {
+	benchmarkRunLines(b, true)
+}
+
+func BenchmarkRunLinesUnpooled(b *testing.B) {
+	benchmarkRunLines(b, false)
+}
+
+func benchmarkRunJSON(b *testing.B, pooling bool) {
+	defer func(prev bool) { bufferPooling = prev }(bufferPooling)
+	bufferPooling = pooling
+	app := NewApp("sh", "-c", `printf '['; seq -s, 1 10000; printf ']'`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v []int
+		st := app.Clone().RunJSON(&v, nil)
+		if st.Error != nil || len(v) != 10000 {
+			b.Fatalf("unexpected result: %d items, %+v", len(v), st)
+		}
+	}
+}
+
+func BenchmarkRunJSONPooled(b *testing.B) {
+	benchmarkRunJSON(b, true)
+}
+
+func BenchmarkRunJSONUnpooled(b *testing.B) {
+	benchmarkRunJSON(b, false)
+}
@@ -0,0 +1,205 @@
+package shell
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache keep output and exit code of idempotent (read-only) commands
+// for TTL, so repeated run of application configured with WithCache
+// return stored result instead of executing command again.
+// Cache is safe for concurrent use and can be shared by applications.
+type Cache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	stdout, stderr       []byte
+	hasStdout, hasStderr bool
+	exitCode             int
+	expires              time.Time
+}
+
+// NewCache create cache, which keep results for ttl.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// Purge remove all results.
+func (c *Cache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+}
+
+func (c *Cache) get(key string) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (c *Cache) put(key string, entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	// Drop expired entries, so cache doesn't grow unlimited.
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	entry.expires = now.Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// WithCache make Start to return result stored in cache, if command
+// with the same resolved path, arguments, working directory, backend,
+// values of environments listed in envKeys and stdin content has
+// completed within cache TTL, writing stored stdout and stderr instead
+// of executing it. Only completed runs without error or signal are
+// stored. Stdin passed to Start is read into memory to compute its hash;
+// application with stdin fed via FeedStdin, FIFO or socket is never
+// cached. Stored output is replayed through tees added with
+// TeeStdout/TeeStderr and output filters, while tail, progress,
+// responses and observers are skipped, since nothing is executed.
+func WithCache(c *Cache, envKeys ...string) Option {
+	return func(app *App) {
+		app.cache = c
+		app.cacheEnv = append(app.cacheEnv, envKeys...)
+	}
+}
+
+// cacheable report, whether stdin allow to cache application.
+func (app *App) cacheable(stdin io.Reader) bool {
+	if app.cache == nil && app.flight == nil || app.stdinFeed != nil || app.socketStdin != nil ||
+		app.stdinFIFO != "" || app.unixSocket != "" {
+		return false
+	}
+	return stdin != Inherit && stdin != os.Stdin
+}
+
+// cacheKey compute cache key of the run, reading stdin into memory,
+// and return stdin to pass to application instead.
+func (app *App) cacheKey(stdin io.Reader) (string, io.Reader, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%q\x00%s\x00%T", app.resolvedPath(), app.cmd.Args[1:],
+		app.cmd.Dir, app.executor)
+	if v := reflect.ValueOf(app.executor); v.Kind() == reflect.Ptr {
+		fmt.Fprintf(h, "@%x", v.Pointer())
+	}
+	env := app.cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	env = append(append([]string{}, env...), app.env...)
+	for _, key := range app.cacheEnv {
+		value := ""
+		for _, item := range env {
+			if strings.HasPrefix(item, key+"=") {
+				value = item
+			}
+		}
+		fmt.Fprintf(h, "\x00%s", value)
+	}
+	h.Write([]byte{0})
+	switch {
+	case stdin != nil && stdin != Discard:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", nil, err
+		}
+		h.Write(data)
+		stdin = bytes.NewReader(data)
+	case stdin == nil && app.stdinFile != "":
+		data, err := os.ReadFile(app.stdinFile)
+		if err != nil {
+			return "", nil, err
+		}
+		h.Write(data)
+	case stdin == nil:
+		h.Write(app.stdinData)
+	}
+	return hex.EncodeToString(h.Sum(nil)), stdin, nil
+}
+
+// replayCache complete run with stored result, if any.
+func (app *App) replayCache(key string, stdout, stderr io.Writer) bool {
+	entry := app.cache.get(key)
+	_, stdout, stderr = resolveStreams(nil, stdout, stderr)
+	if entry == nil || stdout != nil && !entry.hasStdout ||
+		stderr != nil && !entry.hasStderr {
+		return false
+	}
+	app.writeEntry(entry, stdout, stderr)
+	app.startTime = time.Now()
+	app.endTime = app.startTime
+	app.waitCh = make(chan ExitCodeOrError, 1)
+	app.done = make(chan struct{})
+	atomic.StoreInt32(&app.launched, 1)
+	app.sendExitCodeOrError(entry.exitCode, nil)
+	close(app.waitCh)
+	return true
+}
+
+// writeEntry write stored output through tees and filters.
+func (app *App) writeEntry(entry *cacheEntry, stdout, stderr io.Writer) {
+	_, stdout, stderr = resolveStreams(nil, stdout, stderr)
+	stdout, stderr = composeStreams(stdout, stderr, app.stdoutTee, app.stderrTee)
+	stdout = app.filterStream(stdout, "stdout")
+	stderr = app.filterStream(stderr, "stderr")
+	if stdout != nil {
+		stdout.Write(entry.stdout)
+	}
+	if stderr != nil {
+		stderr.Write(entry.stderr)
+	}
+	app.flushStreams()
+}
+
+// cacheRecorder wrap stream destination, recording output to store.
+func (app *App) cacheRecorder(w io.Writer, buf **bytes.Buffer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	*buf = &bytes.Buffer{}
+	return io.MultiWriter(*buf, w)
+}
+
+// storeCache store result of completed run, and share
+// it with applications waiting for it (see WithSingleflight).
+func (app *App) storeCache(exitCode int, err error) {
+	if app.cacheKeyRun == "" {
+		return
+	}
+	entry := &cacheEntry{exitCode: exitCode}
+	if app.cacheStdout != nil {
+		entry.stdout, entry.hasStdout = app.cacheStdout.Bytes(), true
+	}
+	if app.cacheStderr != nil {
+		entry.stderr, entry.hasStderr = app.cacheStderr.Bytes(), true
+	}
+	app.landFlight(entry, err)
+	if app.cache == nil || err != nil || exitCode < 0 || app.ExitSignal() != nil {
+		return
+	}
+	app.cache.put(app.cacheKeyRun, entry)
+}
@@ -0,0 +1,81 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheReplay(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(time.Minute)
+	for i := 0; i < 2; i++ {
+		var stdout bytes.Buffer
+		st := countingApp(dir, "echo out").With(WithCache(cache)).Run(nil, &stdout, nil)
+		if st.Error != nil || st.ExitCode != 0 {
+			t.Fatalf("run %d: unexpected exit status %+v", i, st)
+		}
+		if stdout.String() != "out\n" {
+			t.Fatalf("run %d: unexpected output %q", i, stdout.String())
+		}
+	}
+	if n := countRuns(t, dir); n != 1 {
+		t.Fatalf("command executed %d times, expected once", n)
+	}
+}
+
+func TestCacheReplayWait(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(time.Minute)
+	countingApp(dir, "echo out").With(WithCache(cache)).Run(nil, nil, nil)
+	app := countingApp(dir, "echo out").With(WithCache(cache))
+	if _, err := app.Start(nil, nil, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if st := app.Wait(); st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+}
+
+func TestCacheExitCode(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(time.Minute)
+	for i := 0; i < 2; i++ {
+		st := countingApp(dir, "exit 3").With(WithCache(cache)).Run(nil, nil, nil)
+		if st.ExitCode != 3 {
+			t.Fatalf("run %d: unexpected exit status %+v", i, st)
+		}
+	}
+	if n := countRuns(t, dir); n != 1 {
+		t.Fatalf("command executed %d times, expected once", n)
+	}
+}
+
+func TestCacheSkipSignaled(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(time.Minute)
+	for i := 0; i < 2; i++ {
+		countingApp(dir, "kill -KILL $$").With(WithCache(cache)).Run(nil, nil, nil)
+	}
+	if n := countRuns(t, dir); n != 2 {
+		t.Fatalf("command executed %d times, expected twice", n)
+	}
+}
+
+func TestCacheStdinKey(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(time.Minute)
+	for _, in := range []string{"a", "b", "a"} {
+		var stdout bytes.Buffer
+		countingApp(dir, "cat").With(WithCache(cache)).Run(strings.NewReader(in), &stdout, nil)
+		if stdout.String() != in {
+			t.Fatalf("unexpected output %q for input %q", stdout.String(), in)
+		}
+	}
+	if n := countRuns(t, dir); n != 2 {
+		t.Fatalf("command executed %d times, expected twice", n)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// WithAmbientCaps start application with ambient capabilities
+// specified (CAP_* constants from golang.org/x/sys/unix), so non-root
+// application keeps them after exec. Calling process must have them
+// in both permitted and inheritable sets.
+func WithAmbientCaps(caps ...uintptr) Option {
+	return func(app *App) {
+		app.SysProcAttr().AmbientCaps = append([]uintptr{}, caps...)
+	}
+}
+
+// WithDropAllCaps start application without any capabilities, even
+// if it's run by root: bounding and inheritable capability sets
+// are cleared for the thread application is forked from. If calling
+// process lacks CAP_SETPCAP to do so, application is started with
+// no_new_privs flag, so it can't gain capabilities via setuid
+// or file capabilities. Only LocalExecutor is supported.
+func WithDropAllCaps() Option {
+	return func(app *App) {
+		app.SysProcAttr().AmbientCaps = nil
+		app.threadSetup = append(app.threadSetup, dropAllCaps)
+	}
+}
+
+const (
+	prCapBSetDrop       = 24
+	prSetNoNewPrivs     = 38
+	prCapAmbient        = 47
+	prCapAmbientClear   = 4
+	linuxCapVersion3    = 0x20080522
+	defaultLastCapIndex = 40
+)
+
+type capHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+func prctl(option, arg uintptr) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg, 0, 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// lastCap return the highest capability index supported by kernel.
+func lastCap() int {
+	data, err := os.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return defaultLastCapIndex
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return defaultLastCapIndex
+	}
+	return n
+}
+
+// dropAllCaps clear capabilities inherited by process
+// forked from the current thread.
+func dropAllCaps() error {
+	for c := 0; c <= lastCap(); c++ {
+		err := prctl(prCapBSetDrop, uintptr(c))
+		if err == syscall.EPERM {
+			// Unprivileged process, so prevent gaining
+			// capabilities on exec instead.
+			return prctl(prSetNoNewPrivs, 1)
+		}
+		if err != nil && err != syscall.EINVAL {
+			return os.NewSyscallError("prctl", err)
+		}
+	}
+	hdr := capHeader{version: linuxCapVersion3}
+	var data [2]capData
+	_, _, errno := syscall.RawSyscall(syscall.SYS_CAPGET,
+		uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0)
+	if errno != 0 {
+		return os.NewSyscallError("capget", errno)
+	}
+	data[0].inheritable, data[1].inheritable = 0, 0
+	_, _, errno = syscall.RawSyscall(syscall.SYS_CAPSET,
+		uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0)
+	if errno != 0 {
+		return os.NewSyscallError("capset", errno)
+	}
+	// Ambient set is cleared with inheritable one, but make it explicit.
+	prctl(prCapAmbient, prCapAmbientClear)
+	return nil
+}
@@ -0,0 +1,210 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetSize is a maximum size of output
+// attached to error messages.
+const snippetSize = 256
+
+// snippet return beginning of output trimmed
+// to be attached to error message.
+func snippet(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) > snippetSize {
+		return string(data[:snippetSize]) + "..."
+	}
+	return string(data)
+}
+
+// runCapture run application synchronously capturing stdout, and
+// verify that application succeeded. In case of non-zero exit code
+// error is set in status, including stderr snippet. Otherwise stdout
+// is passed to handle, which error is set in status. Buffers are
+// taken from the pool, so handle must not keep reference to output.
+func (app *App) runCapture(stdin io.Reader, handle func(out []byte) error) ExitCodeOrError {
+	stdout, stderr := getBuffer(), getBuffer()
+	defer putBuffer(stdout)
+	defer putBuffer(stderr)
+	st := app.Run(stdin, stdout, stderr)
+	if st.Error == nil && st.ExitCode != 0 {
+		st.Error = fmt.Errorf("%s exited with code %d: %s",
+			app.Name(), st.ExitCode, snippet(stderr.Bytes()))
+	}
+	if st.Error == nil {
+		st.Error = handle(stdout.Bytes())
+	}
+	return st
+}
+
+// RunJSON start application synchronously, verify that
+// it succeeded and decode JSON from stdout into v. This is a common
+// pattern to wrap tools like "kubectl -o json" or "docker inspect".
+// Errors include snippet of stderr (when application failed)
+// or stdout (when output is not valid JSON).
+func (app *App) RunJSON(v interface{}, stdin io.Reader) ExitCodeOrError {
+	return app.runCapture(stdin, func(out []byte) error {
+		err := json.Unmarshal(out, v)
+		if err != nil {
+			return fmt.Errorf("can't decode JSON output of %s: %v, output: %q",
+				app.Name(), err, snippet(out))
+		}
+		return nil
+	})
+}
+
+// RunVerbose run application synchronously, showing its output
+// live on stdout and stderr of the calling process and capturing
+// it at the same time, so output is both seen and available
+// to the caller afterwards.
+func (app *App) RunVerbose(stdin io.Reader) ([]byte, []byte, ExitCodeOrError) {
+	var stdout, stderr bytes.Buffer
+	st := app.Run(stdin, io.MultiWriter(os.Stdout, &stdout),
+		io.MultiWriter(os.Stderr, &stderr))
+	return stdout.Bytes(), stderr.Bytes(), st
+}
+
+// linesConfig keep settings of RunLines output splitting.
+type linesConfig struct {
+	delimiter string
+	keepEmpty bool
+	noTrim    bool
+	maxLength int
+	binary    func(chunk []byte)
+}
+
+// LinesOption configure RunLines output splitting.
+type LinesOption func(c *linesConfig)
+
+// LinesDelimiter split output with delimiter instead of new line.
+func LinesDelimiter(delimiter string) LinesOption {
+	return func(c *linesConfig) {
+		c.delimiter = delimiter
+	}
+}
+
+// LinesKeepEmpty keep empty lines, which are filtered by default.
+func LinesKeepEmpty() LinesOption {
+	return func(c *linesConfig) {
+		c.keepEmpty = true
+	}
+}
+
+// LinesNoTrim keep leading and trailing spaces of lines,
+// which are trimmed by default.
+func LinesNoTrim() LinesOption {
+	return func(c *linesConfig) {
+		c.noTrim = true
+	}
+}
+
+// LinesMaxLength split lines longer than n bytes into pieces
+// at most n bytes long (at UTF-8 rune boundary, if possible),
+// so single huge line doesn't produce huge string.
+func LinesMaxLength(n int) LinesOption {
+	return func(c *linesConfig) {
+		c.maxLength = n
+	}
+}
+
+// LinesBinary enable binary detection: if output contain NUL byte
+// within first binarySniffSize bytes, it's not split into lines,
+// but passed to fn in chunks instead, and RunLines return no lines.
+// Chunk must not be retained after fn returns.
+func LinesBinary(fn func(chunk []byte)) LinesOption {
+	return func(c *linesConfig) {
+		c.binary = fn
+	}
+}
+
+// binarySniffSize is a size of output prefix, inspected
+// to detect binary data, the same as git does.
+const binarySniffSize = 8000
+
+// binaryChunkSize is a size of chunks binary output is passed with.
+const binaryChunkSize = 32 * 1024
+
+// isBinary detect binary data by NUL byte in the beginning.
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
+
+// splitLong split line into pieces not longer than n bytes.
+func splitLong(line string, n int) []string {
+	var pieces []string
+	for len(line) > n {
+		i := n
+		// Don't cut multibyte rune, unless line is not UTF-8 at all.
+		for i > n-utf8.UTFMax && i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		if i == 0 || !utf8.RuneStart(line[i]) {
+			i = n
+		}
+		pieces = append(pieces, line[:i])
+		line = line[i:]
+	}
+	return append(pieces, line)
+}
+
+// RunLines start application synchronously, verify that it succeeded
+// and return stdout split into lines, trimmed and with empty ones
+// filtered by default, which is the way to handle the many commands
+// whose output is a simple list. See LinesMaxLength and LinesBinary
+// to handle commands, which might produce huge lines or binary data.
+func (app *App) RunLines(stdin io.Reader, options ...LinesOption) ([]string, ExitCodeOrError) {
+	c := &linesConfig{delimiter: "\n"}
+	for _, option := range options {
+		option(c)
+	}
+	var lines []string
+	st := app.runCapture(stdin, func(out []byte) error {
+		if c.binary != nil && isBinary(out) {
+			for len(out) > 0 {
+				n := len(out)
+				if n > binaryChunkSize {
+					n = binaryChunkSize
+				}
+				c.binary(out[:n])
+				out = out[n:]
+			}
+			return nil
+		}
+		text := string(out)
+		for _, line := range strings.Split(text, c.delimiter) {
+			if c.noTrim {
+				line = strings.TrimSuffix(line, "\r")
+			} else {
+				line = strings.TrimSpace(line)
+			}
+			if line == "" && !c.keepEmpty {
+				continue
+			}
+			if c.maxLength > 0 && len(line) > c.maxLength {
+				lines = append(lines, splitLong(line, c.maxLength)...)
+				continue
+			}
+			lines = append(lines, line)
+		}
+		if c.keepEmpty && len(lines) > 0 && lines[len(lines)-1] == "" &&
+			strings.HasSuffix(text, c.delimiter) {
+			// Drop artifact of trailing delimiter.
+			lines = lines[:len(lines)-1]
+		}
+		return nil
+	})
+	if st.Error != nil {
+		return nil, st
+	}
+	return lines, st
+}
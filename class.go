@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ExecClass is a name of execution class, which bundle scheduling
+// attributes of application (see ClassProfile and WithClass).
+type ExecClass string
+
+// Predefined execution classes, which profiles
+// can be tuned with SetClassProfile.
+const (
+	// ClassBatch is for background jobs, which shouldn't disturb others.
+	ClassBatch ExecClass = "batch"
+	// ClassInteractive is for jobs user waiting for.
+	ClassInteractive ExecClass = "interactive"
+	// ClassRealtime is for latency critical jobs; privileges are
+	// required to raise priority (CAP_SYS_NICE, CAP_SYS_ADMIN for
+	// realtime I/O class and CAP_SYS_RESOURCE for negative OOM score).
+	ClassRealtime ExecClass = "realtime"
+)
+
+// IOClass is an I/O scheduling class, as ionice(1) define.
+type IOClass int
+
+const (
+	// IOClassNone keep I/O priority inherited.
+	IOClassNone IOClass = iota
+	// IOClassRealtime get disk access first, regardless of others.
+	IOClassRealtime
+	// IOClassBestEffort share disk access according to level.
+	IOClassBestEffort
+	// IOClassIdle get disk access, only when nobody else need it.
+	IOClassIdle
+)
+
+// ClassProfile define scheduling attributes of execution class.
+// Zero value of every field keep attribute inherited from the
+// calling process. Supported on Linux only.
+type ClassProfile struct {
+	// Nice is a CPU scheduling priority from -20 (highest) to 19.
+	Nice int
+	// IOClass and IOLevel (0 highest, 7 lowest, for realtime
+	// and best-effort classes) define I/O priority.
+	IOClass IOClass
+	IOLevel int
+	// OOMScoreAdj from -1000 to 1000 make application
+	// less or more preferable victim of OOM killer.
+	OOMScoreAdj int
+	// Cgroup is a cgroup v2 directory (relative to /sys/fs/cgroup,
+	// unless absolute) application is placed in from the start;
+	// it's created, if missing, which require delegated hierarchy.
+	Cgroup string
+	// CPUWeight and IOWeight from 1 to 10000 (100 is default)
+	// are written to cpu.weight and io.weight of Cgroup.
+	CPUWeight int
+	IOWeight  int
+}
+
+func (p ClassProfile) validate() error {
+	if p.Nice < -20 || p.Nice > 19 {
+		return fmt.Errorf("nice %d out of range", p.Nice)
+	}
+	if p.IOClass < IOClassNone || p.IOClass > IOClassIdle {
+		return fmt.Errorf("unknown I/O class %d", p.IOClass)
+	}
+	if p.IOLevel < 0 || p.IOLevel > 7 {
+		return fmt.Errorf("I/O level %d out of range", p.IOLevel)
+	}
+	if p.OOMScoreAdj < -1000 || p.OOMScoreAdj > 1000 {
+		return fmt.Errorf("OOM score adjustment %d out of range", p.OOMScoreAdj)
+	}
+	if p.CPUWeight < 0 || p.CPUWeight > 10000 || p.IOWeight < 0 || p.IOWeight > 10000 {
+		return fmt.Errorf("cgroup weight out of range")
+	}
+	if p.Cgroup == "" && (p.CPUWeight != 0 || p.IOWeight != 0) {
+		return fmt.Errorf("cgroup weights require cgroup")
+	}
+	return nil
+}
+
+var (
+	classMutex    sync.RWMutex
+	classProfiles = map[ExecClass]ClassProfile{
+		ClassBatch:       {Nice: 10, IOClass: IOClassBestEffort, IOLevel: 7, OOMScoreAdj: 500},
+		ClassInteractive: {IOClass: IOClassBestEffort, IOLevel: 4},
+		ClassRealtime:    {Nice: -10, IOClass: IOClassRealtime, IOLevel: 4, OOMScoreAdj: -500},
+	}
+)
+
+// SetClassProfile define or replace profile of execution class, so
+// operators tune classes centrally rather than per call site. Profile
+// is looked up on every start, so change affect applications
+// configured already.
+func SetClassProfile(class ExecClass, p ClassProfile) error {
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("class %q: %v", class, err)
+	}
+	classMutex.Lock()
+	defer classMutex.Unlock()
+	classProfiles[class] = p
+	return nil
+}
+
+// GetClassProfile return profile of execution class, false if unknown.
+func GetClassProfile(class ExecClass) (ClassProfile, bool) {
+	classMutex.RLock()
+	defer classMutex.RUnlock()
+	p, ok := classProfiles[class]
+	return p, ok
+}
+
+// WithClass start application with scheduling attributes of execution
+// class (see ClassProfile). Unknown class is reported by Start.
+// Nice and I/O priority are set for the thread application is forked
+// from, so only LocalExecutor is supported; OOM score is adjusted
+// right after start.
+func WithClass(class ExecClass) Option {
+	return func(app *App) {
+		app.execClass = class
+	}
+}
+
+// classProfile return profile of application class, nil if none.
+func (app *App) classProfile() (*ClassProfile, error) {
+	if app.execClass == "" {
+		return nil, nil
+	}
+	p, ok := GetClassProfile(app.execClass)
+	if !ok {
+		return nil, fmt.Errorf("unknown execution class %q", app.execClass)
+	}
+	if app.executor != LocalExecutor {
+		// Pid of the process in container doesn't refer to host one.
+		return nil, errors.New("execution classes are supported by local executor only")
+	}
+	return &p, nil
+}
@@ -0,0 +1,94 @@
+//go:build linux
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// classCmd prepare start of application in execution class: thread
+// setup for priorities and cgroup application is placed in.
+func (app *App) classCmd(cmd *exec.Cmd) (*exec.Cmd, error) {
+	app.classSetup, app.classOOM = nil, 0
+	p, err := app.classProfile()
+	if err != nil || p == nil {
+		return cmd, err
+	}
+	if p.Nice != 0 {
+		nice := p.Nice
+		app.classSetup = append(app.classSetup, func() error {
+			// Priority of the thread is inherited by process forked.
+			return syscall.Setpriority(syscall.PRIO_PROCESS, syscall.Gettid(), nice)
+		})
+	}
+	if p.IOClass != IOClassNone {
+		prio := uintptr(p.IOClass)<<ioprioClassShift | uintptr(p.IOLevel)
+		app.classSetup = append(app.classSetup, func() error {
+			_, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess,
+				uintptr(syscall.Gettid()), prio)
+			if errno != 0 {
+				return fmt.Errorf("set I/O priority: %v", errno)
+			}
+			return nil
+		})
+	}
+	app.classOOM = p.OOMScoreAdj
+	if p.Cgroup == "" {
+		return cmd, nil
+	}
+	dir := p.Cgroup
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join("/sys/fs/cgroup", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.procs")); err != nil {
+		return nil, fmt.Errorf("%s is not a cgroup v2 directory", dir)
+	}
+	if p.CPUWeight != 0 {
+		if err := os.WriteFile(filepath.Join(dir, "cpu.weight"),
+			[]byte(strconv.Itoa(p.CPUWeight)), 0644); err != nil {
+			return nil, err
+		}
+	}
+	if p.IOWeight != 0 {
+		if err := os.WriteFile(filepath.Join(dir, "io.weight"),
+			[]byte("default "+strconv.Itoa(p.IOWeight)), 0644); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	app.closers = append(app.closers, f)
+	c := *cmd
+	var attr syscall.SysProcAttr
+	if cmd.SysProcAttr != nil {
+		attr = *cmd.SysProcAttr
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(f.Fd())
+	c.SysProcAttr = &attr
+	return &c, nil
+}
+
+// classStarted adjust OOM score of application started.
+func (app *App) classStarted(pid int) error {
+	if app.classOOM == 0 {
+		return nil
+	}
+	return os.WriteFile("/proc/"+strconv.Itoa(pid)+"/oom_score_adj",
+		[]byte(strconv.Itoa(app.classOOM)), 0644)
+}
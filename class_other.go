@@ -0,0 +1,19 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func (app *App) classCmd(cmd *exec.Cmd) (*exec.Cmd, error) {
+	if app.execClass != "" {
+		return nil, errors.New("execution classes are supported on Linux only")
+	}
+	return cmd, nil
+}
+
+func (app *App) classStarted(pid int) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	contextMutex   sync.RWMutex
+	defaultContext context.Context
+)
+
+// SetDefaultContext bind every application started afterwards to
+// context: application is killed (see App.Kill), once context is
+// cancelled, and Start fail with context error, if it is done already.
+// This is a global shutdown hook for programs spawning children
+// from many places. Nil remove binding of applications started later.
+func SetDefaultContext(ctx context.Context) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+	defaultContext = ctx
+}
+
+// boundContext return package default context, nil if not set.
+func boundContext() context.Context {
+	contextMutex.RLock()
+	defer contextMutex.RUnlock()
+	return defaultContext
+}
+
+// checkContext fail, if default context is done already.
+func (app *App) checkContext() error {
+	app.ctx = boundContext()
+	if app.ctx == nil {
+		return nil
+	}
+	return app.ctx.Err()
+}
+
+// watchContext kill application, once context is cancelled.
+func (app *App) watchContext() {
+	if app.ctx == nil || app.ctx.Done() == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-app.ctx.Done():
+			app.Kill()
+		case <-app.done:
+		}
+	}()
+}
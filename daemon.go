@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"os"
+)
+
+// DaemonEnv is an environment variable, which mark
+// the copy of the process started by Daemonize.
+const DaemonEnv = "GO_SHELL_DAEMON"
+
+// DaemonOptions configure Daemonize.
+type DaemonOptions struct {
+	// Dir is a working directory of the daemon, "/" if empty.
+	Dir string
+	// Stdout and Stderr are files, which daemon output is appended
+	// to, /dev/null if empty. Stdin is always /dev/null.
+	Stdout, Stderr string
+	// PidFile is a file daemon pid is written to, if not empty.
+	// Daemonize fails, if this file refer to the process alive.
+	PidFile string
+	// Env list environments added to the daemon ones.
+	Env []string
+}
+
+// Daemonize turn the current program into daemon: executable is run
+// again with the same arguments fully detached (see StartDetached: new
+// session, no controlling terminal, reparented to init), in directory
+// and with stdio specified by opts, and the calling process exits.
+// In the daemon copy Daemonize return nil immediately, so it must be
+// called early in main, before any work is done:
+//
+//	if err := shell.Daemonize(shell.DaemonOptions{PidFile: "/run/app.pid"}); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Error is returned only in the calling process, if daemon failed to start.
+func Daemonize(opts DaemonOptions) error {
+	if os.Getenv(DaemonEnv) != "" {
+		os.Unsetenv(DaemonEnv)
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	app := NewApp(exe, os.Args[1:]...).
+		With(WithDetachedOutput(opts.Stdout, opts.Stderr))
+	if opts.PidFile != "" {
+		app.With(WithPidFile(opts.PidFile))
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "/"
+	}
+	app.SetDir(dir)
+	app.AddEnvironments(append(append([]string{}, opts.Env...), DaemonEnv+"=1"))
+	_, err = app.StartDetached()
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
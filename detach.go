@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// WithDetachedOutput specify files, which stdout and stderr of
+// application started with StartDetached are appended to (created,
+// if not exist). Empty path mean /dev/null, which is the default.
+// Both streams can share the same file.
+func WithDetachedOutput(stdout, stderr string) Option {
+	return func(app *App) {
+		app.detachedStdout = stdout
+		app.detachedStderr = stderr
+	}
+}
+
+// StartDetached launch application fully detached from the current
+// process and return its pid: process run in new session without
+// controlling terminal, stdin is read from file set with SetStdinFile
+// (or /dev/null), stdout and stderr are redirected according to
+// WithDetachedOutput. Process is not a child of the current one,
+// so it keeps running after the current process exits and never
+// become a zombie here. Only LocalExecutor is supported;
+// observers, events, tees and output filters don't apply.
+func (app *App) StartDetached() (int, error) {
+	if app.err != nil {
+		return 0, app.err
+	}
+	if app.executor != LocalExecutor {
+		return 0, errors.New("detached start is supported by local executor only")
+	}
+	if !IsLinuxMacOSFreeBSD() {
+		return 0, errors.New("detached start require POSIX shell")
+	}
+	if err := app.renderArgs(); err != nil {
+		return 0, err
+	}
+	app.globArgs()
+	app.lookExtraPath()
+	if err := app.checkPolicy(); err != nil {
+		return 0, err
+	}
+	if err := app.checkArgs(); err != nil {
+		return 0, err
+	}
+	if err := app.checkPidFile(); err != nil {
+		return 0, err
+	}
+	secrets, err := app.fetchSecrets()
+	if err != nil {
+		return 0, err
+	}
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	open := func(path string, flag int) (*os.File, error) {
+		if path == "" {
+			path = os.DevNull
+		}
+		file, err := os.OpenFile(path, flag, 0644)
+		if err == nil {
+			files = append(files, file)
+		}
+		return file, err
+	}
+	stdin, err := open(app.stdinFile, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	stdout, err := open(app.detachedStdout, flag)
+	if err != nil {
+		return 0, err
+	}
+	stderr := stdout
+	if app.detachedStderr != app.detachedStdout {
+		stderr, err = open(app.detachedStderr, flag)
+		if err != nil {
+			return 0, err
+		}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	files = append(files, w)
+	// Intermediate shell start the process in background, report
+	// its pid and exit, so process is reparented to init. Stdin is
+	// passed with extra descriptor, since non-interactive shell
+	// connect stdin of background job to /dev/null.
+	pidFd := 3 + len(app.cmd.ExtraFiles)
+	stdinFd := pidFd + 1
+	if stdinFd > 9 {
+		// POSIX shell redirect single digit descriptors only.
+		return 0, errors.New("too many extra files for detached start")
+	}
+	pidArg, stdinArg := strconv.Itoa(pidFd), strconv.Itoa(stdinFd)
+	script := `"$@" 0<&` + stdinArg + " " + stdinArg + "<&- " + pidArg +
+		`>&- & echo "$!" >&` + pidArg
+	if app.umaskSet {
+		script = fmt.Sprintf("umask %04o && ", app.umask) + script
+	}
+	args := append([]string{"-c", script, "sh", app.cmd.Path}, app.cmd.Args[1:]...)
+	shim := exec.Command("/bin/sh", args...)
+	shim.Dir = app.cmd.Dir
+	shim.Env = app.cmd.Env
+	shim = app.extraPathCmd(shim)
+	if len(secrets) > 0 {
+		shim, _ = injectSecrets(shim, nil, secrets)
+	}
+	shim.Stdout, shim.Stderr = stdout, stderr
+	shim.ExtraFiles = append(append([]*os.File{}, app.cmd.ExtraFiles...), w, stdin)
+	shim.SysProcAttr = &syscall.SysProcAttr{}
+	setNewSession(shim.SysProcAttr)
+	err = shim.Start()
+	if err != nil {
+		return 0, err
+	}
+	w.Close()
+	files = files[:len(files)-1]
+	line, rerr := bufio.NewReader(r).ReadString('\n')
+	err = shim.Wait()
+	if err != nil {
+		return 0, err
+	}
+	if rerr != nil {
+		return 0, errors.New("detached process identifier is not reported")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, err
+	}
+	return pid, app.writePidFile(pid, false)
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStartDetachedStdin(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte("data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp("cat").With(WithDetachedOutput(out, ""))
+	app.SetStdinFile(in)
+	if _, err := app.StartDetached(); err != nil {
+		t.Fatalf("StartDetached: %v", err)
+	}
+	// Detached process is not a child, so poll for its output.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if string(data) == "data\n" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stdin file is not read by detached process, output %q", data)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
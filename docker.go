@@ -0,0 +1,235 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// DockerExecutor is a backend which run applications inside existing
+// Docker container via "docker exec" command. Any Docker CLI compatible
+// tool (for instance, podman) can be used, changing Binary field.
+// Container must provide POSIX shell, which is used to report
+// process identifier, so Signal and Kill are delivered to the process
+// inside container rather than to the local CLI process only.
+type DockerExecutor struct {
+	// Container is a name or ID of the running container.
+	Container string
+	// User run process as specified user, if not empty,
+	// in the form "name|uid[:group|gid]".
+	User string
+	// Binary is an executable of Docker CLI, "docker" if empty.
+	Binary string
+}
+
+// NewDockerExecutor create executor which run applications
+// inside container with name or ID specified.
+func NewDockerExecutor(container string) *DockerExecutor {
+	return &DockerExecutor{Container: container}
+}
+
+func (e *DockerExecutor) binary() string {
+	if e.Binary == "" {
+		return "docker"
+	}
+	return e.Binary
+}
+
+// Start implement Executor interface.
+func (e *DockerExecutor) Start(cmd *exec.Cmd, env []string) (Process, error) {
+	args := []string{"exec"}
+	if cmd.Stdin != nil {
+		args = append(args, "-i")
+	}
+	if e.User != "" {
+		args = append(args, "-u", e.User)
+	}
+	if cmd.Dir != "" {
+		args = append(args, "-w", cmd.Dir)
+	}
+	for _, item := range env {
+		args = append(args, "-e", item)
+	}
+	args = append(args, e.Container)
+	args = append(args, pidShimArgs("", nil, cmd.Args)...)
+	signal := func(pid int, sig syscall.Signal) []string {
+		return []string{"exec", e.Container,
+			"sh", "-c", fmt.Sprintf("kill -%d %d", sig, pid)}
+	}
+	return startShimProcess(e.binary(), args, cmd, signal)
+}
+
+// pidShimArgs wrap command with POSIX shell, which change
+// working directory (if specified), export environments env,
+// verify command exists, report own pid to stderr and then replace
+// itself with the command. Pid is not reported, if command is not
+// found, so exit code 127 of the command itself is not taken for
+// the launch failure.
+func pidShimArgs(dir string, env, args []string) []string {
+	script := `command -v "$1" >/dev/null || exit 127; echo "$$" >&2; exec "$@"`
+	if len(env) > 0 {
+		quoted := make([]string, len(env))
+		for i, item := range env {
+			quoted[i] = QuoteArg(item)
+		}
+		script = "export " + strings.Join(quoted, " ") + " && " + script
+	}
+	if dir != "" {
+		script = "cd " + QuoteArg(dir) + " && " + script
+	}
+	return append([]string{"sh", "-c", script, "sh"}, args...)
+}
+
+// shimProcess run local CLI tool (docker, kubectl and so on),
+// which in turn run the command somewhere else, wrapped with pid shim.
+// Signals are delivered running the same tool with
+// arguments produced by signal function.
+type shimProcess struct {
+	binary string
+	local  Process
+	pid    *pidWriter
+	signal func(pid int, sig syscall.Signal) []string
+}
+
+func startShimProcess(binary string, args []string, cmd *exec.Cmd,
+	signal func(pid int, sig syscall.Signal) []string) (Process, error) {
+
+	pw := &pidWriter{out: cmd.Stderr}
+	local := exec.Command(binary, args...)
+	local.SysProcAttr = newSysProcAttr()
+	local.Stdin = cmd.Stdin
+	local.Stdout = cmd.Stdout
+	local.Stderr = pw
+	p, err := LocalExecutor.Start(local, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &shimProcess{binary: binary, local: p, pid: pw, signal: signal}, nil
+}
+
+// Pid return process identifier inside container,
+// or 0 if process has not reported it yet.
+func (p *shimProcess) Pid() int {
+	pid, _ := p.pid.get()
+	return pid
+}
+
+// Wait translate exit code of the CLI tool: if pid
+// has never been reported, then tool failed to run
+// the command, so its output become an error message,
+// where exit codes 126 and 127 mean, that command
+// can't be invoked or is not found. Once pid is reported,
+// exit code is the one of the command, whatever it is.
+func (p *shimProcess) Wait() (int, error) {
+	exitCode, err := p.local.Wait()
+	if err != nil {
+		return exitCode, err
+	}
+	pid, msg := p.pid.get()
+	if pid != 0 {
+		return exitCode, nil
+	}
+	msg = bytes.TrimSpace(msg)
+	switch {
+	case exitCode == 126:
+		err = errors.New("command found, but can't be invoked")
+	case exitCode == 127:
+		err = errors.New("command not found")
+	default:
+		err = errors.New("can't execute command")
+	}
+	if len(msg) > 0 {
+		err = fmt.Errorf("%v: %s", err, msg)
+	}
+	return 0, err
+}
+
+func (p *shimProcess) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	pid := p.Pid()
+	if pid == 0 {
+		return errors.New("process identifier is not known yet")
+	}
+	st := NewApp(p.binary, p.signal(pid, s)...).Run(nil, nil, nil)
+	if st.Error != nil {
+		return st.Error
+	}
+	if st.ExitCode != 0 {
+		return fmt.Errorf("can't deliver signal %v to process %d", sig, pid)
+	}
+	return nil
+}
+
+func (p *shimProcess) Kill() error {
+	if pid := p.Pid(); pid != 0 {
+		err := p.Signal(syscall.SIGKILL)
+		if err != nil {
+			return err
+		}
+	}
+	err := p.local.Kill()
+	if err == syscall.ESRCH {
+		// CLI tool exited already, once process has been killed.
+		return nil
+	}
+	return err
+}
+
+// pidWriter extract first line with process identifier
+// from the stream and pass the rest to the destination writer.
+type pidWriter struct {
+	sync.Mutex
+	out  io.Writer
+	done bool
+	pid  int
+	buf  []byte
+}
+
+func (w *pidWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.done {
+		if w.out == nil {
+			return len(p), nil
+		}
+		return w.out.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	i := bytes.IndexByte(w.buf, '\n')
+	if i == -1 {
+		return len(p), nil
+	}
+	w.done = true
+	rest := w.buf
+	if pid, err := strconv.Atoi(string(w.buf[:i])); err == nil {
+		w.pid = pid
+		rest = w.buf[i+1:]
+	}
+	if w.out != nil && len(rest) > 0 {
+		_, err := w.out.Write(rest)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if w.pid != 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// get return pid, if reported, otherwise all output collected.
+func (w *pidWriter) get() (int, []byte) {
+	w.Lock()
+	defer w.Unlock()
+	return w.pid, w.buf
+}
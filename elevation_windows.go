@@ -0,0 +1,159 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// WithElevation launch application with UAC elevation, as
+// "Run as administrator" does, using ShellExecuteEx with "runas" verb,
+// so user is asked for consent (ErrElevationCancelled is reported
+// by Start, if declined). Wait and exit code work as usual, Signal
+// and Kill terminate the process only, but not its children.
+// Windows doesn't allow to redirect stdio of elevated process,
+// nor pass environments to it: application get own console
+// window and environments of the administrator session.
+func WithElevation() Option {
+	return func(app *App) {
+		app.SetExecutor(elevatedExecutor{})
+	}
+}
+
+// ErrElevationCancelled is returned by Start of application
+// configured WithElevation, if user declined UAC prompt.
+var ErrElevationCancelled = errors.New("elevation is cancelled by user")
+
+const (
+	seeMaskNoCloseProcess = 0x00000040
+	seeMaskNoAsync        = 0x00000100
+	seeMaskFlagNoUI       = 0x00000400
+)
+
+// shellExecuteInfo is SHELLEXECUTEINFOW structure.
+type shellExecuteInfo struct {
+	cbSize         uint32
+	fMask          uint32
+	hwnd           uintptr
+	lpVerb         *uint16
+	lpFile         *uint16
+	lpParameters   *uint16
+	lpDirectory    *uint16
+	nShow          int32
+	hInstApp       uintptr
+	lpIDList       uintptr
+	lpClass        *uint16
+	hkeyClass      uintptr
+	dwHotKey       uint32
+	hIconOrMonitor uintptr
+	hProcess       windows.Handle
+}
+
+var procShellExecuteEx = windows.NewLazySystemDLL("shell32.dll").NewProc("ShellExecuteExW")
+
+type elevatedExecutor struct{}
+
+// Start implement Executor interface.
+func (elevatedExecutor) Start(cmd *exec.Cmd, env []string) (Process, error) {
+	verb, err := windows.UTF16PtrFromString("runas")
+	if err != nil {
+		return nil, err
+	}
+	file, err := windows.UTF16PtrFromString(cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+	var params []string
+	for _, arg := range cmd.Args[1:] {
+		params = append(params, syscall.EscapeArg(arg))
+	}
+	info := shellExecuteInfo{
+		fMask:  seeMaskNoCloseProcess | seeMaskNoAsync | seeMaskFlagNoUI,
+		lpVerb: verb,
+		lpFile: file,
+		nShow:  windows.SW_SHOWNORMAL,
+	}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+	info.lpParameters, err = windows.UTF16PtrFromString(strings.Join(params, " "))
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Dir != "" {
+		info.lpDirectory, err = windows.UTF16PtrFromString(cmd.Dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r, _, err := procShellExecuteEx.Call(uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		if err == windows.ERROR_CANCELLED {
+			return nil, ErrElevationCancelled
+		}
+		return nil, &os.PathError{Op: "ShellExecuteEx", Path: cmd.Path, Err: err}
+	}
+	if info.hProcess == 0 {
+		// Request is served by already running instance
+		// of the application, so there is nothing to wait.
+		return nil, errors.New("elevated process handle is not provided")
+	}
+	return &elevatedProcess{handle: info.hProcess}, nil
+}
+
+type elevatedProcess struct {
+	sync.Mutex
+	handle windows.Handle
+}
+
+func (p *elevatedProcess) Pid() int {
+	p.Lock()
+	defer p.Unlock()
+	if p.handle == 0 {
+		return 0
+	}
+	pid, _ := windows.GetProcessId(p.handle)
+	return int(pid)
+}
+
+func (p *elevatedProcess) Wait() (int, error) {
+	p.Lock()
+	h := p.handle
+	p.Unlock()
+	_, err := windows.WaitForSingleObject(h, windows.INFINITE)
+	if err != nil {
+		return 0, err
+	}
+	var code uint32
+	err = windows.GetExitCodeProcess(h, &code)
+	p.Lock()
+	windows.CloseHandle(h)
+	p.handle = 0
+	p.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return int(code), nil
+}
+
+// Signal support os.Kill only, since elevated process
+// doesn't share console with the calling one.
+func (p *elevatedProcess) Signal(sig os.Signal) error {
+	if sig != os.Kill {
+		return errors.New("only kill signal is supported by elevated process")
+	}
+	return p.Kill()
+}
+
+func (p *elevatedProcess) Kill() error {
+	p.Lock()
+	defer p.Unlock()
+	if p.handle == 0 {
+		return os.ErrProcessDone
+	}
+	return windows.TerminateProcess(p.handle, 1)
+}
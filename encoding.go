@@ -0,0 +1,42 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// WithOutputEncoding make application output to be transcoded from
+// encoding specified (IANA or WHATWG name, like "windows-1251",
+// "cp866" or "koi8-r") to UTF-8 on the fly, which is needed to wrap
+// legacy tools running with non-UTF-8 locale.
+// Unknown encoding name make Start to fail.
+func WithOutputEncoding(name string) Option {
+	return func(app *App) {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			app.setError(fmt.Errorf("unknown output encoding %q: %v", name, err))
+			return
+		}
+		app.outputEncoding = enc
+	}
+}
+
+func (app *App) encodingFilter(w io.Writer, stream string) io.Writer {
+	if app.outputEncoding == nil {
+		return w
+	}
+	return &decodeWriter{transform.NewWriter(w, app.outputEncoding.NewDecoder())}
+}
+
+// decodeWriter flush incomplete multi-byte sequence on exit.
+type decodeWriter struct {
+	*transform.Writer
+}
+
+func (w *decodeWriter) Flush() error {
+	// Close doesn't close underlying writer, only flush the rest of data.
+	return w.Writer.Close()
+}
@@ -0,0 +1,246 @@
+package shell
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvProvider supply environments (secrets, mostly) in the form
+// "key=value", which are fetched every time application starts and
+// passed to application process only: they are not kept in App, so
+// don't appear in Clone, environments of the other applications or
+// logs, and their values are masked in the output, as WithRedact does.
+type EnvProvider interface {
+	Env() ([]string, error)
+}
+
+// EnvProviderFunc is an adapter to use function as EnvProvider.
+type EnvProviderFunc func() ([]string, error)
+
+// Env implement EnvProvider interface.
+func (f EnvProviderFunc) Env() ([]string, error) {
+	return f()
+}
+
+// WithEnvProvider add provider, which environments
+// are injected into application process on start.
+func WithEnvProvider(p EnvProvider) Option {
+	return func(app *App) {
+		app.envProviders = append(app.envProviders, p)
+	}
+}
+
+// fetchSecrets query all providers.
+func (app *App) fetchSecrets() ([]string, error) {
+	var env []string
+	for i, p := range app.envProviders {
+		items, err := p.Env()
+		if err != nil {
+			return nil, fmt.Errorf("can't fetch environments: %v", err)
+		}
+		for j, item := range items {
+			if !strings.Contains(item, "=") {
+				// Item without key might be the secret itself, so never show it.
+				return nil, fmt.Errorf("environment %d of provider %d (%T) is not in the form key=value",
+					j, i, p)
+			}
+		}
+		env = append(env, items...)
+	}
+	return env, nil
+}
+
+// injectSecrets return copy of cmd with secrets added to its
+// environments, and executor environments with secrets added.
+func injectSecrets(cmd *exec.Cmd, env, secrets []string) (*exec.Cmd, []string) {
+	c := *cmd
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	c.Env = append(append([]string{}, base...), secrets...)
+	return &c, append(append([]string{}, env...), secrets...)
+}
+
+// redactSecrets wrap stream destination with writer masking
+// values of secrets, which is flushed on exit.
+func (app *App) redactSecrets(w io.Writer, secrets []string) io.Writer {
+	if w == nil {
+		return nil
+	}
+	var patterns []*regexp.Regexp
+	for _, item := range secrets {
+		value := item[strings.IndexByte(item, '=')+1:]
+		if value != "" {
+			patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(value)))
+		}
+	}
+	if len(patterns) == 0 {
+		return w
+	}
+	r := &redactWriter{app: &App{appConfig: appConfig{redact: patterns}}, w: w}
+	app.flushers = append(app.flushers, r)
+	return r
+}
+
+// EnvFile return provider, which read environments from file
+// in dotenv format: "key=value" lines, optionally prefixed with
+// "export", with value optionally quoted; empty lines and lines
+// starting with "#" are ignored.
+func EnvFile(path string) EnvProvider {
+	return EnvProviderFunc(func() ([]string, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		var env []string
+		scanner := bufio.NewScanner(file)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+			i := strings.IndexByte(line, '=')
+			if i <= 0 {
+				return nil, fmt.Errorf("%s:%d: line is not in the form key=value", path, n)
+			}
+			key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+			if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+				if s, err := strconv.Unquote(value); err == nil {
+					value = s
+				}
+			} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+				value = value[1 : len(value)-1]
+			}
+			env = append(env, key+"="+value)
+		}
+		return env, scanner.Err()
+	})
+}
+
+// EnvKeyring return provider, which read secrets stored in the system
+// keyring for service with account names equal to keys: with
+// secret-tool (libsecret) on Linux and FreeBSD, where secret is looked up
+// by attributes "service" and "account", and security utility on macOS,
+// where secret is a generic password.
+func EnvKeyring(service string, keys ...string) EnvProvider {
+	return EnvProviderFunc(func() ([]string, error) {
+		var env []string
+		for _, key := range keys {
+			var app *App
+			if runtime.GOOS == "darwin" {
+				app = NewApp("security", "find-generic-password",
+					"-s", service, "-a", key, "-w")
+			} else {
+				app = NewApp("secret-tool", "lookup",
+					"service", service, "account", key)
+			}
+			var value string
+			st := app.runCapture(nil, func(out []byte) error {
+				value = strings.TrimSuffix(string(out), "\n")
+				return nil
+			})
+			if st.Error != nil {
+				return nil, fmt.Errorf("can't read secret %q of service %q: %v",
+					key, service, st.Error)
+			}
+			env = append(env, key+"="+value)
+		}
+		return env, nil
+	})
+}
+
+// VaultProvider is EnvProvider, which read secret from HashiCorp Vault
+// via HTTP API, every key of the secret become an environment.
+// Both KV version 1 and 2 secret engines are supported.
+type VaultProvider struct {
+	// Addr is a Vault server address, VAULT_ADDR environment if empty.
+	Addr string
+	// Token is a Vault token, VAULT_TOKEN environment if empty.
+	Token string
+	// Path is a secret path, for instance "secret/data/myapp"
+	// for KV version 2 mounted at "secret".
+	Path string
+	// Client is HTTP client, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// EnvVault return provider, which read secret at path
+// from Vault addressed with VAULT_ADDR and VAULT_TOKEN environments.
+func EnvVault(path string) *VaultProvider {
+	return &VaultProvider{Path: path}
+}
+
+// Env implement EnvProvider interface.
+func (p *VaultProvider) Env() ([]string, error) {
+	addr, token := p.Addr, p.Token
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if addr == "" {
+		return nil, errors.New("Vault address is not specified")
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+
+		strings.TrimPrefix(p.Path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault replied %s reading %q", resp.Status, p.Path)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&secret)
+	if err != nil {
+		return nil, err
+	}
+	data := secret.Data
+	// KV version 2 nest secret into data with metadata aside.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, ok := data[key].(string)
+		if !ok {
+			b, _ := json.Marshal(data[key])
+			value = string(b)
+		}
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
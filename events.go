@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// EventType identify kind of lifecycle event.
+type EventType int
+
+// Lifecycle events emitted for every application.
+const (
+	// EventStarting is emitted right before application start.
+	EventStarting EventType = iota
+	// EventStarted is emitted once application started, with Pid.
+	EventStarted
+	// EventOutputTruncated is emitted once captured output exceed limit.
+	EventOutputTruncated
+	// EventExited is emitted once application finished, with ExitCode,
+	// either with Error, if application failed to start or complete.
+	EventExited
+	// EventKilled is emitted once application killed, with Signal.
+	EventKilled
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStarting:
+		return "Starting"
+	case EventStarted:
+		return "Started"
+	case EventOutputTruncated:
+		return "OutputTruncated"
+	case EventExited:
+		return "Exited"
+	case EventKilled:
+		return "Killed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a structured lifecycle notification.
+// Fields not relevant for event type are left zero.
+type Event struct {
+	Type     EventType
+	App      *App
+	Time     time.Time
+	Pid      int
+	ExitCode int
+	Error    error
+	Signal   os.Signal
+}
+
+var (
+	subscribersMutex sync.RWMutex
+	subscribers      = make(map[int]func(Event))
+	lastSubscriber   int
+)
+
+// Subscribe register function to receive lifecycle events
+// of all applications, and return function to unsubscribe.
+// Callback is called synchronously from the package internals,
+// so it must not block, but may unsubscribe.
+func Subscribe(fn func(Event)) (unsubscribe func()) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+	lastSubscriber++
+	id := lastSubscriber
+	subscribers[id] = fn
+	return func() {
+		subscribersMutex.Lock()
+		defer subscribersMutex.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+func publish(e Event) {
+	e.Time = time.Now()
+	// Callbacks are called without lock held,
+	// so they can subscribe and unsubscribe.
+	subscribersMutex.RLock()
+	list := make([]func(Event), 0, len(subscribers))
+	for _, fn := range subscribers {
+		list = append(list, fn)
+	}
+	subscribersMutex.RUnlock()
+	for _, fn := range list {
+		fn(e)
+	}
+}
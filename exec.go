@@ -0,0 +1,26 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Exec replace the current process with executable name (resolved
+// against PATH, unless name contain path separator) run with args
+// (program name excluded), keeping pid, so signals sent to the current
+// process are received by the new program. Environments are inherited,
+// if env is nil. Argument zero is name as specified, like shell does.
+// On success Exec never return.
+func Exec(name string, args, env []string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	argv := append([]string{name}, args...)
+	err = syscall.Exec(path, argv, env)
+	return &os.PathError{Op: "exec", Path: path, Err: err}
+}
@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Executor is a backend which start process described by cmd.
+// Executable path, arguments, working directory and stdio
+// are taken from cmd fields, env contains only environments
+// added on top of inherited ones (see App.AddEnvironments),
+// which is what remote backends should pass to the process.
+type Executor interface {
+	Start(cmd *exec.Cmd, env []string) (Process, error)
+}
+
+// Process is a handle of the process started by Executor.
+type Process interface {
+	// Pid return process identifier, or 0 if backend can't provide it.
+	Pid() int
+	// Wait block until process exits and return exit code
+	// (-1, if process terminated by signal), either error.
+	Wait() (int, error)
+	// Signal send signal to the process.
+	Signal(sig os.Signal) error
+	// Kill terminate process immediately.
+	Kill() error
+}
+
+// signaledProcess is implemented by Process, which
+// can report the signal that terminated the process.
+type signaledProcess interface {
+	ExitSignal() os.Signal
+}
+
+// groupSignaler is implemented by Process, which can deliver
+// signal to all processes of the group process is a leader of.
+type groupSignaler interface {
+	SignalGroup(sig syscall.Signal) error
+}
+
+// LocalExecutor is a default backend, which run
+// applications on the local host via exec.Cmd.
+var LocalExecutor Executor = localExecutor{}
+
+type localExecutor struct{}
+
+// Start implement Executor interface.
+func (localExecutor) Start(cmd *exec.Cmd, env []string) (Process, error) {
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &localProcess{cmd: cmd}, nil
+}
+
+type localProcess struct {
+	cmd    *exec.Cmd
+	signal os.Signal
+}
+
+func (p *localProcess) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *localProcess) Wait() (int, error) {
+	err := p.cmd.Wait()
+	var exitCode int
+	if err != nil {
+		if exiterr, ok := err.(*exec.ExitError); ok {
+			if stat, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+				exitCode = stat.ExitStatus()
+				if stat.Signaled() {
+					p.signal = stat.Signal()
+				}
+				// reset error, since exitCode already not equal to zero
+				err = nil
+			}
+		}
+	}
+	return exitCode, err
+}
+
+func (p *localProcess) ExitSignal() os.Signal {
+	return p.signal
+}
+
+func (p *localProcess) Signal(sig os.Signal) error {
+	return p.cmd.Process.Signal(sig)
+}
+
+func (p *localProcess) Kill() error {
+	return p.SignalGroup(syscall.SIGKILL)
+}
+
+// SignalGroup send signal to the process with all its children,
+// if process is a leader of its group, and to the process only otherwise.
+func (p *localProcess) SignalGroup(sig syscall.Signal) error {
+	// Signal not only main but all child processes,
+	// so extract for this purpose group id.
+	pid := p.cmd.Process.Pid
+	pgid, err := getpgid(pid, p.cmd.SysProcAttr)
+	// Process might share group with the calling one (see SetSysProcAttr),
+	// which must survive.
+	if err == nil && pgid == pid {
+		return signalGroup(pgid, sig)
+	}
+	// Signal only mother process
+	return p.cmd.Process.Signal(sig)
+}
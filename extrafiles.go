@@ -0,0 +1,40 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// AddExtraFile pass open file to the application as additional
+// file descriptor, and return its number in the child process
+// (the first extra file get descriptor 3, following stdio).
+// Supported by local executor only.
+func (app *App) AddExtraFile(f *os.File) int {
+	app.cmd.ExtraFiles = append(app.cmd.ExtraFiles, f)
+	return 2 + len(app.cmd.ExtraFiles)
+}
+
+// AddExtraFileEnv pass open file to the application as additional
+// file descriptor and communicate its number in environment
+// variable name, to be picked up by child with ExtraFileFromEnv.
+func (app *App) AddExtraFileEnv(name string, f *os.File) int {
+	fd := app.AddExtraFile(f)
+	app.AddEnvironments([]string{name + "=" + strconv.Itoa(fd)})
+	return fd
+}
+
+// ExtraFileFromEnv is a child side helper, which return file
+// for descriptor passed by parent with AddExtraFileEnv.
+func ExtraFileFromEnv(name string) (*os.File, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return nil, fmt.Errorf("environment variable %s contain invalid descriptor %q",
+			name, value)
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
@@ -0,0 +1,56 @@
+package shell
+
+// AppFactory hold options shared by many applications (environments,
+// working directory, PATH additions, observers, limits and so on) and
+// create applications preconfigured with them, so call sites don't
+// repeat the same options. Factory is immutable, so it is safe for
+// concurrent use; With derive new factory with more options. Since
+// Option is a plain function, any App method can be applied as well:
+//
+//	f := NewAppFactory(WithDir("/srv"), WithLocale("C"),
+//		func(app *App) { app.TeeStderr(logWriter) })
+//	app := f.NewApp("ls", "-l")
+type AppFactory struct {
+	options []Option
+}
+
+// NewAppFactory create factory applying options to every application.
+func NewAppFactory(options ...Option) *AppFactory {
+	return &AppFactory{options: append([]Option{}, options...)}
+}
+
+// With return new factory, which apply options
+// after options of this one.
+func (f *AppFactory) With(options ...Option) *AppFactory {
+	list := make([]Option, 0, len(f.options)+len(options))
+	list = append(append(list, f.options...), options...)
+	return &AppFactory{options: list}
+}
+
+// NewApp create application as NewApp do, and apply options of factory.
+// Options applied later with App.With can override defaults.
+func (f *AppFactory) NewApp(name string, args ...string) *App {
+	return NewApp(name, args...).With(f.options...)
+}
+
+// WithEnv add environments in the form "key=value",
+// see App.AddEnvironments.
+func WithEnv(env ...string) Option {
+	return func(app *App) {
+		app.AddEnvironments(env)
+	}
+}
+
+// WithDir change working directory of application, see App.SetDir.
+func WithDir(dir string) Option {
+	return func(app *App) {
+		app.SetDir(dir)
+	}
+}
+
+// WithExecutor set backend of application, see App.SetExecutor.
+func WithExecutor(executor Executor) Option {
+	return func(app *App) {
+		app.SetExecutor(executor)
+	}
+}
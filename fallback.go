@@ -0,0 +1,44 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoAlternative is reported by FirstAvailable,
+// if none of alternatives is installed.
+var ErrNoAlternative = errors.New("none of alternative commands is available")
+
+// FirstAvailable try alternative commands in order (for instance,
+// "ip" and then "ifconfig", "python3" and then "python") and run
+// the first one, which is installed (see App.CheckIsInstalled)
+// and succeed (exit with zero code). Applications use stdio
+// configured on every App, so output of alternative failed is
+// written there as well. Alternative used is returned with its
+// exit status; if all failed, the last one (nil, if none is installed)
+// is returned with error describing every attempt.
+func FirstAvailable(specs ...*App) (*App, ExitCodeOrError) {
+	var errs []error
+	var last *App
+	var st ExitCodeOrError
+	for _, app := range specs {
+		if err := app.CheckIsInstalled(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", app.Name(), err))
+			continue
+		}
+		last = app
+		st = app.Run(nil, nil, nil)
+		if st.Error == nil && st.ExitCode == 0 {
+			return app, st
+		}
+		if st.Error != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", app.Name(), st.Error))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: exit code %d", app.Name(), st.ExitCode))
+		}
+	}
+	if last == nil {
+		errs = append([]error{ErrNoAlternative}, errs...)
+	}
+	return last, ExitCodeOrError{ExitCode: st.ExitCode, Error: errors.Join(errs...)}
+}
@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"errors"
+	"os"
+)
+
+// WithStdinFIFO connect application stdin to named pipe created
+// at path (and removed once application exits). Same as shell
+// redirection, Start blocks until external writer open the pipe.
+func WithStdinFIFO(path string) Option {
+	return func(app *App) {
+		app.stdinFIFO = path
+	}
+}
+
+// WithStdoutFIFO connect application stdout to named pipe created
+// at path (and removed once application exits), so external consumer
+// can attach to the stream without going through the Go process.
+// Start doesn't wait for consumer, application block once pipe buffer
+// is full. Writer passed to Run/Start, tees and output filters
+// don't apply to the stream.
+func WithStdoutFIFO(path string) Option {
+	return func(app *App) {
+		app.stdoutFIFO = path
+	}
+}
+
+// WithStderrFIFO connect application stderr to named pipe,
+// same way as WithStdoutFIFO does.
+func WithStderrFIFO(path string) Option {
+	return func(app *App) {
+		app.stderrFIFO = path
+	}
+}
+
+// openFIFO create named pipe and open it, registering
+// file to close and pipe to remove once application exits.
+func (app *App) openFIFO(path string, flag int) (*os.File, error) {
+	err := mkfifo(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "mkfifo", Path: path, Err: err}
+	}
+	file, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	app.closers = append(app.closers, file, fifoRemover(path))
+	return file, nil
+}
+
+type fifoRemover string
+
+func (path fifoRemover) Close() error {
+	return os.Remove(string(path))
+}
+
+// connectFIFOs replace stdio of the command with named pipes configured.
+func (app *App) connectFIFOs(stdin, stdout, stderr bool) error {
+	if app.stdinFIFO != "" {
+		if stdin {
+			return errors.New("stdin is connected to FIFO, but reader is passed")
+		}
+		// Opening for read block until writer open the pipe.
+		file, err := app.openFIFO(app.stdinFIFO, os.O_RDONLY)
+		if err != nil {
+			return err
+		}
+		app.cmd.Stdin = file
+	}
+	// Open for read and write, so open doesn't block
+	// waiting for consumer.
+	if app.stdoutFIFO != "" {
+		if stdout {
+			return errors.New("stdout is connected to FIFO, but writer is passed")
+		}
+		file, err := app.openFIFO(app.stdoutFIFO, os.O_RDWR)
+		if err != nil {
+			return err
+		}
+		app.cmd.Stdout = file
+	}
+	if app.stderrFIFO != "" {
+		if stderr {
+			return errors.New("stderr is connected to FIFO, but writer is passed")
+		}
+		file, err := app.openFIFO(app.stderrFIFO, os.O_RDWR)
+		if err != nil {
+			return err
+		}
+		app.cmd.Stderr = file
+	}
+	return nil
+}
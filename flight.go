@@ -0,0 +1,124 @@
+package shell
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Flight deduplicate concurrent runs of identical commands: while
+// application configured with WithSingleflight is running, applications
+// with the same command spec started with the same Flight don't execute
+// command, but wait for the running one and receive its output and exit
+// status, as singleflight does for function calls. It prevents thundering
+// herd of identical expensive commands (hardware probes and so on)
+// in concurrent servers. Flight is safe for concurrent use.
+type Flight struct {
+	mutex sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	entry *cacheEntry
+	err   error
+}
+
+// NewFlight create empty flight group.
+func NewFlight() *Flight {
+	return &Flight{calls: make(map[string]*flightCall)}
+}
+
+// InFlight return number of commands executing now.
+func (f *Flight) InFlight() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.calls)
+}
+
+// WithSingleflight make Start to share execution with application
+// running within f, if any, which has the same resolved path, arguments,
+// working directory, backend, values of environments listed in envKeys,
+// stdin content and set of streams captured (see WithCache for details
+// of the key). Application sharing execution has no process (Pid return 0,
+// Kill fail with ErrNotStarted); its output and exit status, including
+// failure to start, are those of the executing one. Output is replayed
+// through tees added with TeeStdout/TeeStderr and output filters, once
+// executing application exits. Combined with WithCache, cached result
+// is looked up first.
+func WithSingleflight(f *Flight, envKeys ...string) Option {
+	return func(app *App) {
+		app.flight = f
+		app.cacheEnv = append(app.cacheEnv, envKeys...)
+	}
+}
+
+// Shared report, whether application has received result
+// of execution shared by another one (see WithSingleflight).
+func (app *App) Shared() bool {
+	return app.flightShared
+}
+
+// flightKey extend cache key with streams captured,
+// since shared run must record all of them.
+func flightKey(key string, stdout, stderr io.Writer) string {
+	_, stdout, stderr = resolveStreams(nil, stdout, stderr)
+	if stdout != nil {
+		key += "+stdout"
+	}
+	if stderr != nil {
+		key += "+stderr"
+	}
+	return key
+}
+
+// joinFlight either register application as executing the command,
+// or wait in background for the one executing, returning true.
+func (app *App) joinFlight(key string, stdout, stderr io.Writer) bool {
+	key = flightKey(key, stdout, stderr)
+	f := app.flight
+	f.mutex.Lock()
+	call, ok := f.calls[key]
+	if !ok {
+		call = &flightCall{done: make(chan struct{})}
+		f.calls[key] = call
+		f.mutex.Unlock()
+		app.flightCall, app.flightKey = call, key
+		return false
+	}
+	f.mutex.Unlock()
+	app.flightShared = true
+	app.startTime = time.Now()
+	app.waitCh = make(chan ExitCodeOrError, 1)
+	app.done = make(chan struct{})
+	atomic.StoreInt32(&app.launched, 1)
+	go func() {
+		defer close(app.waitCh)
+		<-call.done
+		exitCode := 0
+		if call.entry != nil {
+			app.writeEntry(call.entry, stdout, stderr)
+			exitCode = call.entry.exitCode
+		}
+		app.endTime = time.Now()
+		app.sendExitCodeOrError(exitCode, call.err)
+	}()
+	return true
+}
+
+// landFlight share result of application with ones
+// waiting for it. Entry is nil, if application failed to start.
+func (app *App) landFlight(entry *cacheEntry, err error) {
+	call := app.flightCall
+	if call == nil {
+		return
+	}
+	app.flightCall = nil
+	f := app.flight
+	f.mutex.Lock()
+	delete(f.calls, app.flightKey)
+	f.mutex.Unlock()
+	call.entry, call.err = entry, err
+	close(call.done)
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSingleflightShare(t *testing.T) {
+	dir := t.TempDir()
+	flight := NewFlight()
+	leader := countingApp(dir, "sleep 0.3; echo out").With(WithSingleflight(flight))
+	follower := countingApp(dir, "sleep 0.3; echo out").With(WithSingleflight(flight))
+	var out1, out2 bytes.Buffer
+	ch1, err := leader.Start(nil, &out1, nil)
+	if err != nil {
+		t.Fatalf("Start leader: %v", err)
+	}
+	ch2, err := follower.Start(nil, &out2, nil)
+	if err != nil {
+		t.Fatalf("Start follower: %v", err)
+	}
+	for i, st := range []ExitCodeOrError{<-ch1, <-ch2} {
+		if st.Error != nil || st.ExitCode != 0 {
+			t.Fatalf("app %d: unexpected exit status %+v", i, st)
+		}
+	}
+	if out1.String() != "out\n" || out2.String() != "out\n" {
+		t.Fatalf("unexpected output %q and %q", out1.String(), out2.String())
+	}
+	if leader.Shared() || !follower.Shared() {
+		t.Fatalf("unexpected sharing: leader %v, follower %v",
+			leader.Shared(), follower.Shared())
+	}
+	if follower.Pid() != 0 {
+		t.Fatalf("follower has process %d", follower.Pid())
+	}
+	if n := countRuns(t, dir); n != 1 {
+		t.Fatalf("command executed %d times, expected once", n)
+	}
+}
+
+func TestSingleflightWait(t *testing.T) {
+	dir := t.TempDir()
+	flight := NewFlight()
+	leader := countingApp(dir, "sleep 0.3; exit 4").With(WithSingleflight(flight))
+	follower := countingApp(dir, "sleep 0.3; exit 4").With(WithSingleflight(flight))
+	if _, err := leader.Start(nil, nil, nil); err != nil {
+		t.Fatalf("Start leader: %v", err)
+	}
+	if _, err := follower.Start(nil, nil, nil); err != nil {
+		t.Fatalf("Start follower: %v", err)
+	}
+	if st := follower.Wait(); st.ExitCode != 4 || st.Error != nil {
+		t.Fatalf("unexpected follower exit status %+v", st)
+	}
+	if st := leader.Wait(); st.ExitCode != 4 || st.Error != nil {
+		t.Fatalf("unexpected leader exit status %+v", st)
+	}
+}
+
+func TestSingleflightSequential(t *testing.T) {
+	dir := t.TempDir()
+	flight := NewFlight()
+	for i := 0; i < 2; i++ {
+		countingApp(dir, "true").With(WithSingleflight(flight)).Run(nil, nil, nil)
+	}
+	if n := countRuns(t, dir); n != 2 {
+		t.Fatalf("command executed %d times, expected twice", n)
+	}
+}
@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// WithGlob expand "*", "?" and "[...]" patterns in arguments
+// (executable name excluded) against working directory on start,
+// as shell does, since application is executed without shell.
+// Matches are sorted, relative pattern produce relative paths;
+// pattern without matches is kept intact. Patterns are matched
+// with filepath.Match syntax, where backslash escape metacharacter.
+// Applied after WithArgTemplate rendering.
+func WithGlob() Option {
+	return func(app *App) {
+		app.glob = true
+	}
+}
+
+// globArgs expand argument patterns, if WithGlob is set.
+func (app *App) globArgs() {
+	if !app.glob {
+		return
+	}
+	app.saveArgs()
+	args := []string{app.cmd.Args[0]}
+	for _, arg := range app.cmd.Args[1:] {
+		args = append(args, app.globArg(arg)...)
+	}
+	app.cmd.Args = args
+}
+
+func (app *App) globArg(arg string) []string {
+	if !strings.ContainsAny(arg, "*?[") {
+		return []string{arg}
+	}
+	pattern := arg
+	relative := !filepath.IsAbs(arg) && app.cmd.Dir != ""
+	if relative {
+		pattern = filepath.Join(app.cmd.Dir, arg)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil || len(matches) == 0 {
+		return []string{arg}
+	}
+	if relative {
+		for i, match := range matches {
+			if rel, err := filepath.Rel(app.cmd.Dir, match); err == nil {
+				matches[i] = rel
+			}
+		}
+	}
+	return matches
+}
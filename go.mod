@@ -1,3 +1,13 @@
 module github.com/d2r2/go-shell
 
-go 1.15
+// Go 1.26 is the minimum required by golang.org/x/crypto (SSH backend),
+// x/sys, x/term and x/text; package itself need Go 1.24 (runtime.AddCleanup).
+go 1.26.0
+
+require (
+	golang.org/x/crypto v0.57.0
+	golang.org/x/net v0.58.0
+	golang.org/x/sys v0.48.0
+	golang.org/x/term v0.46.0
+	golang.org/x/text v0.42.0
+)
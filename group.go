@@ -0,0 +1,207 @@
+package shell
+
+import (
+	"errors"
+	"sync"
+)
+
+// Group start applications together and supervise them as a whole,
+// as errgroup does for goroutines: once any application fails (exit
+// with error or non-zero exit code), the rest are killed. This is the
+// common pattern of helpers (servers, port forwarders and so on),
+// which make no sense one without others. Applications use stdio
+// configured on every App (see SetStdin*, TeeStdout, WithDefaultStream).
+type Group struct {
+	apps     []*App
+	mutex    sync.Mutex
+	started  bool
+	killed   bool
+	first    *CommandError
+	statuses []ExitCodeOrError
+	wg       sync.WaitGroup
+}
+
+// NewGroup create group of applications.
+func NewGroup(apps ...*App) *Group {
+	return &Group{apps: apps}
+}
+
+// Add append applications to the group, which is not started yet.
+func (g *Group) Add(apps ...*App) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.started {
+		return errors.New("group is started already")
+	}
+	g.apps = append(g.apps, apps...)
+	return nil
+}
+
+// Apps return applications of the group.
+func (g *Group) Apps() []*App {
+	return g.apps
+}
+
+// Start run all applications asynchronously. If some application
+// fails to start, applications started are killed, and *MultiError
+// describing failure is returned.
+func (g *Group) Start() error {
+	g.mutex.Lock()
+	if g.started {
+		g.mutex.Unlock()
+		return errors.New("group is started already")
+	}
+	g.started = true
+	g.statuses = make([]ExitCodeOrError, len(g.apps))
+	g.mutex.Unlock()
+	for i, app := range g.apps {
+		_, err := app.Start(nil, nil, nil)
+		if err != nil {
+			st := ExitCodeOrError{Error: err}
+			g.fail(i, app, st)
+			for _, started := range g.apps[:i] {
+				started.Kill()
+			}
+			g.mutex.Lock()
+			g.statuses[i] = st
+			for j := i + 1; j < len(g.apps); j++ {
+				g.statuses[j] = ExitCodeOrError{Error: ErrNotStarted}
+			}
+			g.mutex.Unlock()
+			g.wg.Wait()
+			return g.err()
+		}
+		g.wg.Add(1)
+		go g.watch(i, app)
+		if g.down() {
+			// Group is torn down, while applications are being started.
+			app.Kill()
+			g.mutex.Lock()
+			for j := i + 1; j < len(g.apps); j++ {
+				g.statuses[j] = ExitCodeOrError{Error: ErrNotStarted}
+			}
+			g.mutex.Unlock()
+			return nil
+		}
+	}
+	return nil
+}
+
+// down report, whether group is failed or killed.
+func (g *Group) down() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.first != nil || g.killed
+}
+
+// watch wait for application and tear down
+// the group, once application failed.
+func (g *Group) watch(i int, app *App) {
+	defer g.wg.Done()
+	st := app.wait()
+	g.mutex.Lock()
+	g.statuses[i] = st
+	g.mutex.Unlock()
+	if (st.Error != nil || st.ExitCode != 0) && g.fail(i, app, st) {
+		g.Kill()
+	}
+}
+
+// fail keep the first failure, return true if it is the one.
+func (g *Group) fail(i int, app *App, st ExitCodeOrError) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.first != nil || g.killed {
+		return false
+	}
+	g.first = &CommandError{Index: i, App: app, Status: st, kind: "app"}
+	return true
+}
+
+// err return *MultiError holding the first failure, if any.
+func (g *Group) err() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.first == nil {
+		return nil
+	}
+	return &MultiError{Errors: []*CommandError{g.first}, Total: len(g.apps)}
+}
+
+// Kill terminate all running applications of the group. Failures
+// of applications caused by Kill are not reported by Wait.
+func (g *Group) Kill() error {
+	g.mutex.Lock()
+	g.killed = true
+	g.mutex.Unlock()
+	var first error
+	for _, app := range g.apps {
+		if app.getProcess() == nil {
+			continue
+		}
+		select {
+		case <-app.done:
+			continue
+		default:
+		}
+		err := app.kill()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Wait block until all applications are finished and return exit
+// statuses of all applications in order of Apps, and *MultiError
+// holding the first failure, which tore the group down (nil, if all
+// succeeded or group is killed). Failures caused by teardown
+// are not reported.
+func (g *Group) Wait() ([]ExitCodeOrError, error) {
+	g.mutex.Lock()
+	started := g.started
+	g.mutex.Unlock()
+	if !started {
+		return nil, ErrNotStarted
+	}
+	g.wg.Wait()
+	g.mutex.Lock()
+	statuses := append([]ExitCodeOrError{}, g.statuses...)
+	g.mutex.Unlock()
+	return statuses, g.err()
+}
+
+// Run start group and wait for it, see Start and Wait.
+func (g *Group) Run() ([]ExitCodeOrError, error) {
+	err := g.Start()
+	if err != nil {
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		return append([]ExitCodeOrError{}, g.statuses...), err
+	}
+	return g.Wait()
+}
+
+// RunParallel run applications concurrently with stdio configured on
+// every App and wait for all of them, unlike Group, which tear down
+// applications on the first failure. Exit status of every application
+// is returned, along with *MultiError describing failed ones, if any.
+func RunParallel(apps ...*App) ([]ExitCodeOrError, error) {
+	statuses := make([]ExitCodeOrError, len(apps))
+	var wg sync.WaitGroup
+	for i, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = app.Run(nil, nil, nil)
+		}()
+	}
+	wg.Wait()
+	errs := &MultiError{Total: len(apps)}
+	for i, st := range statuses {
+		if st.Error != nil || st.ExitCode != 0 {
+			errs.add("app", i, apps[i], st)
+		}
+	}
+	return statuses, errs.errOrNil()
+}
@@ -0,0 +1,64 @@
+package shell
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"sync"
+)
+
+// WithGzipCapture stream application stdout and stderr through gzip
+// directly to the file (created or truncated at start), which is useful
+// for archiving verbose logs without post-processing step.
+// File is finalized once application exits.
+func WithGzipCapture(path string) Option {
+	return func(app *App) {
+		app.gzipPath = path
+	}
+}
+
+// gzipWriters create gzip file, if configured,
+// and return its writer shared by both streams.
+func (app *App) gzipWriters() (io.Writer, io.Writer, error) {
+	if app.gzipPath == "" {
+		return nil, nil, nil
+	}
+	file, err := os.Create(app.gzipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := &gzipFileWriter{file: file, gz: gzip.NewWriter(file)}
+	app.flushers = append(app.flushers, w)
+	// Closer finalize file, if start failed after streams are created.
+	app.closers = append(app.closers, w)
+	// The same writer is synchronized by composeStreams.
+	return w, w, nil
+}
+
+type gzipFileWriter struct {
+	file *os.File
+	gz   *gzip.Writer
+	once sync.Once
+	err  error
+}
+
+func (w *gzipFileWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+// Flush finalize gzip stream and close the file,
+// only the first call take effect.
+func (w *gzipFileWriter) Flush() error {
+	w.once.Do(func() {
+		w.err = w.gz.Close()
+		if err := w.file.Close(); w.err == nil {
+			w.err = err
+		}
+	})
+	return w.err
+}
+
+// Close implement io.Closer interface, see Flush.
+func (w *gzipFileWriter) Close() error {
+	return w.Flush()
+}
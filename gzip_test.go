@@ -0,0 +1,55 @@
+//go:build !windows
+
+package shell
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readGzip return decompressed content of file at path.
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip file is not finalized: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("gzip file is truncated: %v", err)
+	}
+	return string(data)
+}
+
+func TestGzipCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.gz")
+	st := NewApp("echo", "data").With(WithGzipCapture(path)).Run(nil, nil, nil)
+	if st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if data := readGzip(t, path); data != "data\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestGzipCaptureFailedStart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.gz")
+	// FIFO fail start after output streams are created.
+	_, err := NewApp("echo", "data").With(WithGzipCapture(path),
+		WithStdinFIFO(filepath.Join(dir, "missing", "fifo"))).Start(nil, nil, nil)
+	if err == nil {
+		t.Fatal("start with broken FIFO succeeded")
+	}
+	if data := readGzip(t, path); data != "" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
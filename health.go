@@ -0,0 +1,194 @@
+package shell
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Health is a state reported by HealthCheck.
+type Health int
+
+const (
+	// HealthUnknown is reported, until thresholds are reached first time.
+	HealthUnknown Health = iota
+	Healthy
+	Unhealthy
+)
+
+func (h Health) String() string {
+	switch h {
+	case Healthy:
+		return "healthy"
+	case Unhealthy:
+		return "unhealthy"
+	}
+	return "unknown"
+}
+
+// HealthCheck run probe command (copy of application, see App.Clone)
+// on interval: zero exit code within Timeout is a success, anything
+// else is a failure. State become Unhealthy after FailureThreshold
+// failures in a row and Healthy after SuccessThreshold successes
+// in a row, as Kubernetes probes do. Configure fields before Start.
+type HealthCheck struct {
+	// Timeout limit probe run time, interval if zero.
+	Timeout time.Duration
+	// FailureThreshold is 3, if zero.
+	FailureThreshold int
+	// SuccessThreshold is 1, if zero.
+	SuccessThreshold int
+
+	app      *App
+	interval time.Duration
+	mutex    sync.Mutex
+	health   Health
+	last     ExitCodeOrError
+	failures int
+	success  int
+	changes  chan Health
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewHealthCheck create health check, which run app on interval.
+func NewHealthCheck(app *App, interval time.Duration) *HealthCheck {
+	return &HealthCheck{app: app, interval: interval,
+		changes: make(chan Health, 1)}
+}
+
+// Start run probes in background, first one immediately.
+func (hc *HealthCheck) Start() error {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	if hc.stop != nil {
+		return errors.New("health check is started already")
+	}
+	if hc.interval <= 0 {
+		return errors.New("health check interval must be positive")
+	}
+	hc.stop = make(chan struct{})
+	hc.done = make(chan struct{})
+	go hc.loop()
+	return nil
+}
+
+// Stop terminate probing, killing probe running, and close
+// channel returned by Changes. Health check can't be restarted.
+func (hc *HealthCheck) Stop() {
+	hc.mutex.Lock()
+	if hc.stop == nil {
+		hc.mutex.Unlock()
+		return
+	}
+	select {
+	case <-hc.stop:
+	default:
+		close(hc.stop)
+	}
+	done := hc.done
+	hc.mutex.Unlock()
+	<-done
+}
+
+// Health return current state.
+func (hc *HealthCheck) Health() Health {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	return hc.health
+}
+
+// LastResult return result of the latest probe.
+func (hc *HealthCheck) LastResult() ExitCodeOrError {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	return hc.last
+}
+
+// Changes return channel, which receive new state on every change.
+// Reader, which is late, get only the latest state.
+func (hc *HealthCheck) Changes() <-chan Health {
+	return hc.changes
+}
+
+func (hc *HealthCheck) loop() {
+	defer close(hc.done)
+	defer close(hc.changes)
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+	for {
+		st, ok := hc.probe()
+		if !ok {
+			return
+		}
+		hc.record(st)
+		select {
+		case <-ticker.C:
+		case <-hc.stop:
+			return
+		}
+	}
+}
+
+// probe run single copy of application, return false, if stopped.
+func (hc *HealthCheck) probe() (ExitCodeOrError, bool) {
+	timeout := hc.Timeout
+	if timeout <= 0 {
+		timeout = hc.interval
+	}
+	app := hc.app.Clone()
+	ch, err := app.Start(nil, nil, nil)
+	if err != nil {
+		return ExitCodeOrError{0, err}, true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case st := <-ch:
+		return st, true
+	case <-timer.C:
+		app.Kill()
+		return ExitCodeOrError{0, errors.New("health probe timed out")}, true
+	case <-hc.stop:
+		app.Kill()
+		return ExitCodeOrError{}, false
+	}
+}
+
+// record update counters and state with probe result.
+func (hc *HealthCheck) record(st ExitCodeOrError) {
+	failureThreshold, successThreshold := hc.FailureThreshold, hc.SuccessThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = 3
+	}
+	if successThreshold <= 0 {
+		successThreshold = 1
+	}
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	hc.last = st
+	health := hc.health
+	if st.Error != nil || st.ExitCode != 0 {
+		hc.failures++
+		hc.success = 0
+		if hc.failures >= failureThreshold {
+			health = Unhealthy
+		}
+	} else {
+		hc.success++
+		hc.failures = 0
+		if hc.success >= successThreshold {
+			health = Healthy
+		}
+	}
+	if health == hc.health {
+		return
+	}
+	hc.health = health
+	// Replace state not received yet with the latest one.
+	select {
+	case <-hc.changes:
+	default:
+	}
+	hc.changes <- health
+}
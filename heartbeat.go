@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHeartbeatLost is reported as exit error of application
+// killed by heartbeat watchdog, see WithHeartbeatFD.
+var ErrHeartbeatLost = errors.New("application heartbeat lost")
+
+// HeartbeatFDEnv is an environment variable, which communicate
+// heartbeat descriptor to the application, see WithHeartbeatFD.
+const HeartbeatFDEnv = "SHELL_HEARTBEAT_FD"
+
+// WithHeartbeatFD require application to write (anything) to the
+// dedicated descriptor, which number is passed in HeartbeatFDEnv
+// environment, at least once per timeout; otherwise application is
+// considered livelocked and killed, with ErrHeartbeatLost reported.
+// Supported by local executor only.
+func WithHeartbeatFD(timeout time.Duration) Option {
+	return func(app *App) {
+		app.heartbeatTimeout = timeout
+		app.heartbeatFD = true
+	}
+}
+
+// WithHeartbeatLine is like WithHeartbeatFD, but heartbeat is
+// a line of stdout or stderr matching regular expression.
+func WithHeartbeatLine(pattern string, timeout time.Duration) Option {
+	return func(app *App) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			app.setError(fmt.Errorf("invalid heartbeat pattern %q: %v", pattern, err))
+			return
+		}
+		app.heartbeatTimeout = timeout
+		app.heartbeatLine = re
+	}
+}
+
+// watchdog kill application, once heartbeats stop.
+type watchdog struct {
+	app     *App
+	timeout time.Duration
+	mutex   sync.Mutex
+	timer   *time.Timer
+	lost    int32
+	pipe    *os.File
+}
+
+func (app *App) newWatchdog() {
+	app.watchdog = nil
+	if app.heartbeatTimeout <= 0 || !app.heartbeatFD && app.heartbeatLine == nil {
+		return
+	}
+	app.watchdog = &watchdog{app: app, timeout: app.heartbeatTimeout}
+}
+
+// beat postpone deadline.
+func (wd *watchdog) beat() {
+	wd.mutex.Lock()
+	defer wd.mutex.Unlock()
+	if wd.timer != nil {
+		wd.timer.Reset(wd.timeout)
+	}
+}
+
+// start arm watchdog, once process started.
+func (wd *watchdog) start() {
+	if wd.pipe != nil {
+		// Keep only child copy, so reader get EOF once child exits.
+		wd.pipe.Close()
+	}
+	wd.mutex.Lock()
+	defer wd.mutex.Unlock()
+	wd.timer = time.AfterFunc(wd.timeout, func() {
+		atomic.StoreInt32(&wd.lost, 1)
+		if process := wd.app.getProcess(); process != nil {
+			process.Kill()
+		}
+	})
+}
+
+// stop disarm watchdog and report, whether heartbeat has been lost.
+func (wd *watchdog) stop() bool {
+	wd.mutex.Lock()
+	defer wd.mutex.Unlock()
+	if wd.timer != nil {
+		wd.timer.Stop()
+	}
+	return atomic.LoadInt32(&wd.lost) != 0
+}
+
+// heartbeatCmd return copy of cmd with heartbeat descriptor passed.
+func (app *App) heartbeatCmd(cmd *exec.Cmd) (*exec.Cmd, error) {
+	if app.watchdog == nil || !app.heartbeatFD {
+		return cmd, nil
+	}
+	if app.executor != LocalExecutor {
+		return nil, errors.New("heartbeat descriptor is supported by local executor only")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	app.closers = append(app.closers, r, w)
+	app.watchdog.pipe = w
+	c := *cmd
+	c.ExtraFiles = append(append([]*os.File{}, cmd.ExtraFiles...), w)
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	c.Env = append(append([]string{}, base...),
+		HeartbeatFDEnv+"="+strconv.Itoa(2+len(c.ExtraFiles)))
+	wd := app.watchdog
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			_, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			wd.beat()
+		}
+	}()
+	return &c, nil
+}
+
+func (app *App) heartbeatWriters() (io.Writer, io.Writer, error) {
+	if app.watchdog == nil || app.heartbeatLine == nil {
+		return nil, nil, nil
+	}
+	return &matchWriter{re: app.heartbeatLine, match: app.watchdog.beat},
+		&matchWriter{re: app.heartbeatLine, match: app.watchdog.beat}, nil
+}
+
+// maxMatchLine limit buffered incomplete line.
+const maxMatchLine = 4096
+
+// matchWriter call match on every line matching expression
+// (beat watchdog, for instance).
+type matchWriter struct {
+	re    *regexp.Regexp
+	match func()
+	buf   []byte
+}
+
+func (w *matchWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		if w.re.Match(w.buf[:i]) {
+			w.match()
+		}
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxMatchLine {
+		w.buf = w.buf[len(w.buf)-maxMatchLine:]
+	}
+	w.buf = append([]byte{}, w.buf...)
+	return len(p), nil
+}
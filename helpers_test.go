@@ -0,0 +1,26 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingApp return application, which append line to the file
+// in dir on every execution, so executions can be counted.
+func countingApp(dir, script string) *App {
+	return NewApp("sh", "-c", "echo run >> "+filepath.Join(dir, "runs")+"; "+script)
+}
+
+// countRuns return number of executions of countingApp.
+func countRuns(t *testing.T, dir string) int {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "runs"))
+	if err != nil {
+		t.Fatalf("read runs: %v", err)
+	}
+	return strings.Count(string(data), "run\n")
+}
@@ -0,0 +1,108 @@
+package shell
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NewStreamHandler return HTTP handler, which on every request
+// start a copy of application (see App.Clone) and stream its
+// stdout/stderr to the client. If client accepts "text/event-stream",
+// output is sent as Server-Sent Events "stdout", "stderr" and
+// final "exit" with exit code. Otherwise both streams are sent
+// as is with chunked transfer encoding, and exit code is reported
+// in "X-Exit-Code" trailer. Process is killed once client disconnects.
+func NewStreamHandler(app *App) http.Handler {
+	return &streamHandler{app: app}
+}
+
+type streamHandler struct {
+	app *App
+}
+
+// ServeHTTP implement http.Handler interface.
+func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Trailer", "X-Exit-Code")
+	}
+	out := &streamWriter{w: w, sse: sse}
+	out.flusher, _ = w.(http.Flusher)
+	app := h.app.Clone()
+	ch, err := app.Start(nil, out.stream("stdout"), out.stream("stderr"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var st ExitCodeOrError
+	select {
+	case st = <-ch:
+	case <-r.Context().Done():
+		// Client gone, so nobody need output anymore.
+		app.Kill()
+		return
+	}
+	if st.Error != nil {
+		out.event("error", st.Error.Error())
+		return
+	}
+	if sse {
+		out.event("exit", strconv.Itoa(st.ExitCode))
+	} else {
+		w.Header().Set("X-Exit-Code", strconv.Itoa(st.ExitCode))
+	}
+}
+
+// streamWriter synchronize writes of both streams
+// to response and flush every chunk to the client.
+type streamWriter struct {
+	sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	sse     bool
+}
+
+func (s *streamWriter) stream(name string) *streamEventWriter {
+	return &streamEventWriter{s: s, name: name}
+}
+
+// event send Server-Sent Event, where every line
+// of data is sent in separate "data" field.
+func (s *streamWriter) event(name, data string) error {
+	s.Lock()
+	defer s.Unlock()
+	var b strings.Builder
+	if s.sse {
+		b.WriteString("event: " + name + "\n")
+		for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+			b.WriteString("data: " + line + "\n")
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString(data)
+	}
+	_, err := s.w.Write([]byte(b.String()))
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return err
+}
+
+type streamEventWriter struct {
+	s    *streamWriter
+	name string
+}
+
+func (w *streamEventWriter) Write(p []byte) (int, error) {
+	err := w.s.event(w.name, string(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,279 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxInterpreterDepth limit chain of interpreted
+// interpreters, as Linux kernel does.
+const maxInterpreterDepth = 4
+
+// CheckIsInstalled find if app is installed or not in the system.
+// Executable is looked up in extra directories (see WithExtraPath) and
+// PATH, or checked directly, if specified with path (relative path is
+// resolved against working directory of application). For interpreted
+// script, interpreter in shebang line ("#!/usr/bin/env python3"
+// included) must exist as well. For busybox/toybox style multi-call
+// binary, invoked via symlink or directly ("busybox ip"), applet must
+// be compiled in.
+// In case of custom backend, Linux/FreeBSD utility [which] is run
+// with the same backend instead.
+func (app *App) CheckIsInstalled() error {
+	if app.executor != LocalExecutor {
+		return app.whichInstalled()
+	}
+	app.lookExtraPath()
+	path := app.resolvedPath()
+	if !strings.ContainsRune(path, filepath.Separator) {
+		return fmt.Errorf("App \"%s\" does not exist", path)
+	}
+	if err := checkExecutable(path); err != nil {
+		return err
+	}
+	if err := checkInterpreter(path, 0); err != nil {
+		return err
+	}
+	return app.checkApplet(path)
+}
+
+// whichInstalled run [which] with application backend.
+func (app *App) whichInstalled() error {
+	// Won't use [whereis], because it doesn't return correct exit code
+	// based on search results. Can use [type], as an option.
+	// Path resolved against local PATH is meaningless for other backends.
+	path := app.cmd.Args[0]
+	whApp := NewApp("which", path)
+	whApp.SetExecutor(app.executor)
+	st := whApp.Run(nil, nil, nil)
+	if st.Error != nil {
+		return st.Error
+	}
+	if st.ExitCode != 0 {
+		return fmt.Errorf("App \"%s\" does not exist", path)
+	}
+	return nil
+}
+
+// checkExecutable verify, that path is executable file.
+func checkExecutable(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("App \"%s\" does not exist", path)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("App \"%s\" is a directory", path)
+	}
+	if runtime.GOOS != "windows" && fi.Mode()&0111 == 0 {
+		return fmt.Errorf("App \"%s\" is not executable", path)
+	}
+	return nil
+}
+
+// checkInterpreter verify, that interpreter of script exists,
+// if path is a script with shebang line.
+func checkInterpreter(path string, depth int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	line, err := bufio.NewReader(io.LimitReader(file, 256)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return nil
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return fmt.Errorf("script \"%s\" has empty shebang line", path)
+	}
+	if depth >= maxInterpreterDepth {
+		return fmt.Errorf("script \"%s\": too many levels of interpreters", path)
+	}
+	interpreter := fields[0]
+	if err := checkExecutable(interpreter); err != nil {
+		return fmt.Errorf("script \"%s\": interpreter: %v", path, err)
+	}
+	if err := checkInterpreter(interpreter, depth+1); err != nil {
+		return err
+	}
+	if filepath.Base(interpreter) != "env" {
+		return nil
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[2:]), interpreter))
+	words, err := splitEnvString(rest)
+	if err != nil {
+		return fmt.Errorf("script \"%s\": %v", path, err)
+	}
+	name, err := envProgram(words)
+	if err != nil {
+		return fmt.Errorf("script \"%s\": %v", path, err)
+	}
+	if name == "" {
+		return nil
+	}
+	program, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("script \"%s\": interpreter \"%s\" does not exist",
+			path, name)
+	}
+	return checkInterpreter(program, depth+1)
+}
+
+// envOptionArg list options of env, which take argument:
+// short ones of GNU and BSD env, and long ones of GNU env.
+var envOptionArg = map[string]bool{
+	"u": true, "C": true, "P": true, "L": true, "a": true, "S": true,
+	"unset": true, "chdir": true, "argv0": true, "split-string": true,
+}
+
+// envProgram return program env run with arguments given, skipping
+// options (with their arguments, as in "env -u NAME") and assignments.
+// Strings of "env -S" (--split-string) are split into arguments, as env
+// does, so "env -S 'python3 -u'" or "env --split-string='python3 -u'" are
+// recognized. Empty name is returned, if program is not specified.
+func envProgram(words []string) (string, error) {
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		var option, value string
+		var hasValue bool
+		switch {
+		case word == "--":
+			if i+1 < len(words) {
+				return words[i+1], nil
+			}
+			return "", nil
+		case strings.HasPrefix(word, "--"):
+			option, value, hasValue = strings.Cut(word[2:], "=")
+		case strings.HasPrefix(word, "-") && word != "-":
+			// Short options might be grouped, as in "-iS".
+			for j := 1; j < len(word); j++ {
+				if envOptionArg[word[j:j+1]] {
+					option, value = word[j:j+1], word[j+1:]
+					hasValue = value != ""
+					break
+				}
+			}
+		case word == "-" || strings.ContainsRune(word, '='):
+			continue
+		default:
+			return word, nil
+		}
+		if !envOptionArg[option] {
+			continue
+		}
+		if !hasValue {
+			i++
+			if i >= len(words) {
+				return "", fmt.Errorf("env option \"%s\" requires argument", option)
+			}
+			value = words[i]
+		}
+		if option != "S" && option != "split-string" {
+			continue
+		}
+		split, err := splitEnvString(value)
+		if err != nil {
+			return "", err
+		}
+		words = append(split, words[i+1:]...)
+		i = -1
+	}
+	return "", nil
+}
+
+// splitEnvString split string into words separated with spaces,
+// taking into account single and double quotes, and backslash
+// escapes, as "env -S" does.
+func splitEnvString(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in \"%s\"", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// checkApplet verify, that applet is compiled into
+// busybox/toybox, if path is a multi-call binary.
+func (app *App) checkApplet(path string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	binary := filepath.Base(target)
+	if binary != "busybox" && binary != "toybox" {
+		return nil
+	}
+	applet := filepath.Base(path)
+	if applet == binary {
+		// Invoked directly, applet is the first argument.
+		args := app.cmd.Args[1:]
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return nil
+		}
+		applet = args[0]
+	}
+	var list *App
+	if binary == "busybox" {
+		list = NewApp(target, "--list")
+	} else {
+		// Toybox list applets, when run without arguments.
+		list = NewApp(target)
+	}
+	out, st := list.RunCombined(nil)
+	if st.Error != nil {
+		return st.Error
+	}
+	if st.ExitCode != 0 {
+		// Built without applet list, can't verify.
+		return nil
+	}
+	for _, name := range strings.Fields(string(out)) {
+		if name == applet {
+			return nil
+		}
+	}
+	return fmt.Errorf("App \"%s\": applet \"%s\" is not compiled into %s",
+		path, applet, binary)
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvProgram(t *testing.T) {
+	cases := []struct {
+		line    string
+		program string
+	}{
+		{"python3", "python3"},
+		{"-i PATH=/bin python3", "python3"},
+		{"-u NAME python3", "python3"},
+		{"-uNAME python3", "python3"},
+		{"--unset NAME python3", "python3"},
+		{"--unset=NAME python3", "python3"},
+		{"-C /tmp sh", "sh"},
+		{`--chdir="/my dir" sh`, "sh"},
+		{"-S python3 -u", "python3"},
+		{"-S 'python3 -u'", "python3"},
+		{"-iS 'A=1 python3'", "python3"},
+		{`--split-string="-u NAME python3 --opt='a b'"`, "python3"},
+		{`--split-string "python3 -u"`, "python3"},
+		{"-- python3", "python3"},
+		{"-i", ""},
+	}
+	for _, c := range cases {
+		words, err := splitEnvString(c.line)
+		if err != nil {
+			t.Fatalf("%q: split: %v", c.line, err)
+		}
+		program, err := envProgram(words)
+		if err != nil {
+			t.Fatalf("%q: %v", c.line, err)
+		}
+		if program != c.program {
+			t.Errorf("%q: program %q, expected %q", c.line, program, c.program)
+		}
+	}
+	for _, line := range []string{"-u", `-S "python3`} {
+		words, err := splitEnvString(line)
+		if err == nil {
+			_, err = envProgram(words)
+		}
+		if err == nil {
+			t.Errorf("%q: malformed line is accepted", line)
+		}
+	}
+}
+
+func TestCheckIsInstalledScript(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, shebang string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(shebang+"\necho ok\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ok := write("ok", "#!/usr/bin/env -u HOME sh")
+	if err := NewApp(ok).CheckIsInstalled(); err != nil {
+		t.Fatalf("script with existing interpreter: %v", err)
+	}
+	missing := write("missing", "#!/usr/bin/env -S 'no-such-interpreter-d2r2 -x'")
+	if err := NewApp(missing).CheckIsInstalled(); err == nil {
+		t.Fatal("script with missing interpreter is reported installed")
+	}
+}
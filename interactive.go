@@ -0,0 +1,23 @@
+package shell
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// RunInteractive run application synchronously with stdin, stdout
+// and stderr of the calling process, which descriptors are inherited
+// directly, so application has the same terminal (if any) and output
+// doesn't go through the Go process. This is what wrapper tools need
+// to hand control to the child tool. If stdin is a terminal,
+// application is run in the process group of the calling process
+// (see WithoutProcessGroup), otherwise it would be stopped by SIGTTIN
+// reading terminal, since only foreground group is allowed to;
+// control keys like Ctrl+C then reach both processes.
+func (app *App) RunInteractive() ExitCodeOrError {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		WithoutProcessGroup()(app)
+	}
+	return app.Run(os.Stdin, os.Stdout, os.Stderr)
+}
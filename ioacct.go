@@ -0,0 +1,138 @@
+package shell
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// IOStats is I/O accounting of application run, see WithIOAccounting.
+type IOStats struct {
+	// StdinBytes, StdoutBytes and StderrBytes count bytes passed
+	// through stdio plumbing of the package (nothing is counted
+	// for streams inherited or connected to FIFO, file and so on
+	// directly, unless accounting itself require copying).
+	StdinBytes  int64 `json:"stdinBytes"`
+	StdoutBytes int64 `json:"stdoutBytes"`
+	StderrBytes int64 `json:"stderrBytes"`
+	// Proc is true, if counters below are taken from /proc/<pid>/io
+	// of the process itself (Linux local processes only).
+	Proc bool `json:"proc,omitempty"`
+	// ReadChars and WriteChars count bytes passed to read and write
+	// syscalls, ReadBytes and WriteBytes ones fetched from and sent
+	// to storage layer.
+	ReadChars  int64 `json:"readChars,omitempty"`
+	WriteChars int64 `json:"writeChars,omitempty"`
+	ReadBytes  int64 `json:"readBytes,omitempty"`
+	WriteBytes int64 `json:"writeBytes,omitempty"`
+}
+
+// WithIOAccounting count bytes of stdin, stdout and stderr passed
+// to and from application, and, on Linux, collect I/O counters of
+// the process from /proc, once it exits. Files passed as streams are
+// copied then instead of being inherited directly. Statistics are
+// returned by App.IOStats and included in App.Report.
+func WithIOAccounting() Option {
+	return func(app *App) {
+		app.ioAccounting = true
+	}
+}
+
+// IOStats return I/O accounting of application, configured
+// with WithIOAccounting option; nil, if option is not set or
+// application is not started. Counters of stdio grow while
+// application is running, /proc ones are set once it exits.
+func (app *App) IOStats() *IOStats {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	acct := app.ioStats
+	if acct == nil {
+		return nil
+	}
+	st := IOStats{Proc: acct.Proc, ReadChars: acct.ReadChars,
+		WriteChars: acct.WriteChars, ReadBytes: acct.ReadBytes,
+		WriteBytes: acct.WriteBytes}
+	st.StdinBytes = atomic.LoadInt64(&acct.StdinBytes)
+	st.StdoutBytes = atomic.LoadInt64(&acct.StdoutBytes)
+	st.StderrBytes = atomic.LoadInt64(&acct.StderrBytes)
+	return &st
+}
+
+// countStdin create accounting, if configured, and wrap stdin with counter.
+func (app *App) countStdin(stdin io.Reader) io.Reader {
+	if !app.ioAccounting {
+		return stdin
+	}
+	acct := &IOStats{}
+	app.mutex.Lock()
+	app.ioStats = acct
+	app.mutex.Unlock()
+	if stdin != nil {
+		stdin = &countReader{r: stdin, n: &acct.StdinBytes}
+	}
+	return stdin
+}
+
+// countOutput wrap output streams with counters, if configured,
+// so raw output is counted before it is passed to tees and filters.
+func (app *App) countOutput(stdout, stderr io.Writer) (io.Writer, io.Writer) {
+	acct := app.ioStats
+	if acct == nil {
+		return stdout, stderr
+	}
+	if stdout != nil && stderr != nil {
+		// Writer shared by streams is not synchronized by exec.Cmd anymore.
+		locks := &writerLocks{}
+		stdout, stderr = locks.get(stdout), locks.get(stderr)
+	}
+	if stdout != nil {
+		stdout = &countWriter{w: stdout, n: &acct.StdoutBytes}
+	}
+	if stderr != nil {
+		stderr = &countWriter{w: stderr, n: &acct.StderrBytes}
+	}
+	return stdout, stderr
+}
+
+// collectProcIO read I/O counters of the process exited, but not reaped.
+func (app *App) collectProcIO(pid int) {
+	app.mutex.Lock()
+	acct := app.ioStats
+	app.mutex.Unlock()
+	if acct == nil || pid == 0 || app.executor != LocalExecutor {
+		return
+	}
+	if err := waitExited(pid); err != nil {
+		return
+	}
+	stats, err := procIO(pid)
+	if err != nil {
+		return
+	}
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	acct.Proc = true
+	acct.ReadChars, acct.WriteChars = stats.ReadChars, stats.WriteChars
+	acct.ReadBytes, acct.WriteBytes = stats.ReadBytes, stats.WriteBytes
+}
+
+type countReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitExited block until process exits, leaving it
+// zombie, so /proc entry is kept till it is reaped.
+func waitExited(pid int) error {
+	var info unix.Siginfo
+	for {
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WEXITED|unix.WNOWAIT, nil)
+		if err != unix.EINTR {
+			return err
+		}
+	}
+}
+
+// procIO read I/O counters of the process from /proc/<pid>/io.
+func procIO(pid int) (*IOStats, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/io")
+	if err != nil {
+		return nil, err
+	}
+	stats := &IOStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "rchar":
+			stats.ReadChars = n
+		case "wchar":
+			stats.WriteChars = n
+		case "read_bytes":
+			stats.ReadBytes = n
+		case "write_bytes":
+			stats.WriteBytes = n
+		}
+	}
+	return stats, nil
+}
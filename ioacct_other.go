@@ -0,0 +1,15 @@
+//go:build !linux
+
+package shell
+
+import "errors"
+
+var errNoProcIO = errors.New("process I/O counters are not supported")
+
+func waitExited(pid int) error {
+	return errNoProcIO
+}
+
+func procIO(pid int) (*IOStats, error) {
+	return nil, errNoProcIO
+}
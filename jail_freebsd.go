@@ -0,0 +1,32 @@
+//go:build freebsd
+
+package shell
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+)
+
+// WithJail run application inside existing FreeBSD jail with
+// identifier jid, using jexec(8), which attach itself to the jail
+// and exec the application, so pid, Signal and Kill refer to the
+// jailed process. Executable is looked up with PATH inside jail.
+func WithJail(jid int) Option {
+	return func(app *App) {
+		if jid <= 0 {
+			app.setError(errors.New("jail identifier must be positive"))
+			return
+		}
+		app.cmdWrappers = append(app.cmdWrappers, func(cmd *exec.Cmd) (*exec.Cmd, error) {
+			jexec, err := exec.LookPath("jexec")
+			if err != nil {
+				return nil, err
+			}
+			wrapped := *cmd
+			wrapped.Path = jexec
+			wrapped.Args = append([]string{"jexec", strconv.Itoa(jid)}, cmd.Args...)
+			return &wrapped, nil
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// KubernetesExecutor is a backend which run applications inside
+// pod container via "kubectl exec", which in turn use the exec
+// subresource of Kubernetes API, streaming stdio over SPDY/WebSocket.
+// Package doesn't talk to the API itself, since that require
+// client-go (kubeconfig, authentication plugins, protocols), so
+// kubectl must be installed. Remote exit code is mapped back, since
+// kubectl terminates with it. Same as DockerExecutor, container must
+// provide POSIX shell.
+type KubernetesExecutor struct {
+	// Pod is a name of the running pod.
+	Pod string
+	// Container is a container name, if pod has more than one.
+	Container string
+	// Namespace is a pod namespace, current one if empty.
+	Namespace string
+	// Context is a kubeconfig context, current one if empty.
+	Context string
+	// Kubeconfig is a path to kubeconfig file, default one if empty.
+	Kubeconfig string
+	// Binary is an executable of kubectl, "kubectl" if empty.
+	Binary string
+}
+
+// NewKubernetesExecutor create executor which run applications
+// inside pod and container specified.
+func NewKubernetesExecutor(namespace, pod, container string) *KubernetesExecutor {
+	return &KubernetesExecutor{Namespace: namespace, Pod: pod,
+		Container: container}
+}
+
+func (e *KubernetesExecutor) binary() string {
+	if e.Binary == "" {
+		return "kubectl"
+	}
+	return e.Binary
+}
+
+// execArgs build kubectl arguments to execute command in the pod.
+func (e *KubernetesExecutor) execArgs(stdin bool, args ...string) []string {
+	var list []string
+	if e.Kubeconfig != "" {
+		list = append(list, "--kubeconfig", e.Kubeconfig)
+	}
+	if e.Context != "" {
+		list = append(list, "--context", e.Context)
+	}
+	list = append(list, "exec")
+	if stdin {
+		list = append(list, "-i")
+	}
+	if e.Namespace != "" {
+		list = append(list, "-n", e.Namespace)
+	}
+	if e.Container != "" {
+		list = append(list, "-c", e.Container)
+	}
+	list = append(list, e.Pod, "--")
+	return append(list, args...)
+}
+
+// Start implement Executor interface.
+func (e *KubernetesExecutor) Start(cmd *exec.Cmd, env []string) (Process, error) {
+	// kubectl exec doesn't support working directory and environments,
+	// so they are applied by shell, which check command exists.
+	args := e.execArgs(cmd.Stdin != nil, pidShimArgs(cmd.Dir, env, cmd.Args)...)
+	signal := func(pid int, sig syscall.Signal) []string {
+		return e.execArgs(false, "sh", "-c", fmt.Sprintf("kill -%d %d", sig, pid))
+	}
+	return startShimProcess(e.binary(), args, cmd, signal)
+}
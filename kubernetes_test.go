@@ -0,0 +1,46 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+// runShim run command wrapped with pid shim locally,
+// as "kubectl exec" does in the pod.
+func runShim(t *testing.T, env []string, args ...string) (string, int, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	p, err := startShimProcess("env", pidShimArgs("/", env, args), cmd, nil)
+	if err != nil {
+		t.Fatalf("start shim: %v", err)
+	}
+	exitCode, err := p.Wait()
+	return stdout.String(), exitCode, err
+}
+
+func TestPidShimEnv(t *testing.T) {
+	out, exitCode, err := runShim(t, []string{"A=b c", "B='q'"},
+		"sh", "-c", `echo "$A $B $PWD"`)
+	if err != nil || exitCode != 0 {
+		t.Fatalf("unexpected exit status %d, %v", exitCode, err)
+	}
+	if out != "b c 'q' /\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestPidShimNotFound(t *testing.T) {
+	_, _, err := runShim(t, []string{"A=1"}, "no-such-command-d2r2")
+	if err == nil || err.Error() != "command not found" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, exitCode, err := runShim(t, []string{"A=1"}, "sh", "-c", "exit 127")
+	if err != nil || exitCode != 127 {
+		t.Fatalf("exit code of command is lost: %d, %v", exitCode, err)
+	}
+}
@@ -0,0 +1,135 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// TruncatePolicy define what to do, once application
+// output exceed limit set by WithMaxCaptureBytes.
+type TruncatePolicy int
+
+const (
+	// TruncateTail pass first bytes up to the limit and discard the rest.
+	TruncateTail TruncatePolicy = iota
+	// KeepHeadTail pass first half of the limit and the last half,
+	// replacing the middle part with truncation marker.
+	KeepHeadTail
+	// KillOnLimit pass bytes up to the limit and kill application.
+	KillOnLimit
+)
+
+// WithMaxCaptureBytes limit amount of output passed to the writers
+// of every stream, protecting against applications, which dump
+// gigabytes to stdout. Excess output is handled according to
+// the policy selected with WithTruncatePolicy (TruncateTail by default).
+// Once limit exceeded, App.Truncated report true.
+func WithMaxCaptureBytes(n int64) Option {
+	return func(app *App) {
+		app.maxCapture = n
+	}
+}
+
+// WithTruncatePolicy select policy used, when limit
+// set with WithMaxCaptureBytes is exceeded.
+func WithTruncatePolicy(policy TruncatePolicy) Option {
+	return func(app *App) {
+		app.truncatePolicy = policy
+	}
+}
+
+// Truncated return true, if output of application
+// has been truncated due to WithMaxCaptureBytes limit.
+func (app *App) Truncated() bool {
+	return atomic.LoadInt32(&app.truncated) != 0
+}
+
+func (app *App) limitFilter(w io.Writer, stream string) io.Writer {
+	if app.maxCapture <= 0 {
+		return w
+	}
+	return &limitWriter{app: app, w: w, limit: app.maxCapture}
+}
+
+// limitWriter apply truncation policy to the stream.
+type limitWriter struct {
+	sync.Mutex
+	app     *App
+	w       io.Writer
+	limit   int64
+	written int64
+	skipped int64
+	tail    []byte
+}
+
+func (l *limitWriter) truncate() {
+	if atomic.SwapInt32(&l.app.truncated, 1) == 0 {
+		publish(Event{Type: EventOutputTruncated, App: l.app, Pid: l.app.Pid()})
+	}
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	head := l.limit
+	if l.app.truncatePolicy == KeepHeadTail {
+		head = l.limit / 2
+	}
+	n := int64(len(p))
+	if l.written < head {
+		chunk := p
+		if int64(len(chunk)) > head-l.written {
+			chunk = chunk[:head-l.written]
+		}
+		_, err := l.w.Write(chunk)
+		if err != nil {
+			return 0, err
+		}
+		l.written += int64(len(chunk))
+		p = p[len(chunk):]
+	}
+	if len(p) == 0 {
+		return int(n), nil
+	}
+	l.truncate()
+	switch l.app.truncatePolicy {
+	case KeepHeadTail:
+		size := int(l.limit - head)
+		l.tail = append(l.tail, p...)
+		if len(l.tail) > size {
+			l.skipped += int64(len(l.tail) - size)
+			l.tail = append(l.tail[:0], l.tail[len(l.tail)-size:]...)
+		}
+	case KillOnLimit:
+		if process := l.app.getProcess(); l.skipped == 0 && process != nil {
+			// Don't block copying routine, since process
+			// might wait for output to be consumed.
+			go process.Kill()
+		}
+		l.skipped += int64(len(p))
+	default:
+		l.skipped += int64(len(p))
+	}
+	// Report all data consumed, so application is not blocked.
+	return int(n), nil
+}
+
+// Flush write kept tail for KeepHeadTail policy.
+func (l *limitWriter) Flush() error {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.tail) == 0 {
+		return nil
+	}
+	if l.skipped > 0 {
+		_, err := fmt.Fprintf(l.w, "\n... [%d bytes truncated] ...\n", l.skipped)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := l.w.Write(l.tail)
+	l.tail = nil
+	return err
+}
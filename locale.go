@@ -0,0 +1,45 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithLocale force locale of application, setting LANG and LC_ALL
+// (which override all LC_* categories inherited) to locale, and clearing
+// LANGUAGE, which otherwise might keep messages translated. Use "C"
+// (or "C.UTF-8") to parse output of tools reliably, since it doesn't
+// depend then on language, number and date formats of the user.
+func WithLocale(locale string) Option {
+	return func(app *App) {
+		app.AddEnvironments([]string{"LANG=" + locale, "LC_ALL=" + locale,
+			"LANGUAGE="})
+	}
+}
+
+// WithLC set locale environments of application, such as LANG,
+// LANGUAGE and LC_* categories (LC_NUMERIC, LC_TIME and so on),
+// for example {"LC_NUMERIC": "C"}. Since LC_ALL override categories,
+// LC_ALL inherited is cleared, unless it is listed as well.
+func WithLC(env map[string]string) Option {
+	return func(app *App) {
+		keys := make([]string, 0, len(env))
+		for key := range env {
+			if key != "LANG" && key != "LANGUAGE" && !strings.HasPrefix(key, "LC_") {
+				app.setError(fmt.Errorf("%q is not a locale environment", key))
+				return
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		list := make([]string, 0, len(keys)+1)
+		if _, ok := env["LC_ALL"]; !ok {
+			list = append(list, "LC_ALL=")
+		}
+		for _, key := range keys {
+			list = append(list, key+"="+env[key])
+		}
+		app.AddEnvironments(list)
+	}
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrAlreadyRunning is returned by Start, when application configured
+// with WithSingleInstance is running already, either by this process
+// or by another one.
+var ErrAlreadyRunning = errors.New("another instance of application is running")
+
+// WithSingleInstance guarantee, that only one copy of application
+// run at a time across processes, using exclusive flock on the file
+// at path (created, if not exist). Lock is held till application exits.
+// Start return ErrAlreadyRunning, if lock is held by someone else.
+func WithSingleInstance(lockPath string) Option {
+	return func(app *App) {
+		app.lockPath = lockPath
+	}
+}
+
+// lockInstance acquire single instance lock, registering
+// lock file to close (and release lock) once application exits.
+func (app *App) lockInstance() error {
+	if app.lockPath == "" {
+		return nil
+	}
+	file, err := os.OpenFile(app.lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		if err == errLocked {
+			return ErrAlreadyRunning
+		}
+		return err
+	}
+	app.closers = append(app.closers, file)
+	return nil
+}
@@ -0,0 +1,135 @@
+package shell
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MarshalArgs convert struct (or pointer to struct) into command line
+// flags according to "flag" field tags, in the order of fields:
+//
+//	type Options struct {
+//		Output  string            `flag:"--output"`  // --output file
+//		Level   int               `flag:"--level="`  // --level=3
+//		Verbose bool              `flag:"-v"`        // -v, if true
+//		Include []string          `flag:"-I"`        // -I a -I b
+//		Labels  map[string]string `flag:"--label"`   // --label k=v, sorted by key
+//		Files   []string          `flag:",positional"`
+//	}
+//
+// Flag ending with "=" is joined with value in single argument.
+// Zero values (empty string, 0, false, empty slice or map, nil pointer)
+// are omitted; use pointer field to pass zero value explicitly.
+// Positional fields are appended after all flags. Fields without
+// tag or with tag "-" are skipped, embedded structs are flattened.
+// Values implementing fmt.Stringer (time.Duration and so on)
+// are formatted with String method.
+func MarshalArgs(v interface{}) []string {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+	var flags, positional []string
+	marshalStruct(val, &flags, &positional)
+	return append(flags, positional...)
+}
+
+func marshalStruct(val reflect.Value, flags, positional *[]string) {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fv := val.Field(i)
+		tag, ok := field.Tag.Lookup("flag")
+		if field.Anonymous && !ok {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				marshalStruct(fv, flags, positional)
+			}
+			continue
+		}
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		explicit := false
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+			explicit = true
+		}
+		if opts == "positional" {
+			*positional = append(*positional, marshalValues(fv, explicit)...)
+			continue
+		}
+		if fv.Kind() == reflect.Bool {
+			if fv.Bool() {
+				*flags = append(*flags, name)
+			} else if explicit && strings.HasSuffix(name, "=") {
+				*flags = append(*flags, name+"false")
+			}
+			continue
+		}
+		for _, value := range marshalValues(fv, explicit) {
+			if strings.HasSuffix(name, "=") {
+				*flags = append(*flags, name+value)
+			} else {
+				*flags = append(*flags, name, value)
+			}
+		}
+	}
+}
+
+// marshalValues format field value: one item for scalar,
+// item per element for slice and "key=value" per entry for map.
+func marshalValues(fv reflect.Value, explicit bool) []string {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			if fv.Len() == 0 {
+				return nil
+			}
+			return []string{string(fv.Bytes())}
+		}
+		values := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			values = append(values, formatValue(fv.Index(i)))
+		}
+		return values
+	case reflect.Map:
+		values := make([]string, 0, fv.Len())
+		iter := fv.MapRange()
+		for iter.Next() {
+			values = append(values, formatValue(iter.Key())+"="+formatValue(iter.Value()))
+		}
+		sort.Strings(values)
+		return values
+	}
+	if !explicit && fv.IsZero() {
+		return nil
+	}
+	return []string{formatValue(fv)}
+}
+
+func formatValue(v reflect.Value) string {
+	if v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,114 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// OutputChunk is a piece of output written by application
+// at once, as it is received from the stream.
+type OutputChunk struct {
+	Stream string
+	Data   []byte
+	Time   time.Time
+}
+
+// MergedCapture record stdout and stderr in the order chunks are
+// received, so interleaving of both streams is preserved as faithfully
+// as two pipes allow: chunks written by application in quick succession
+// to different streams might be received in the reverse order.
+// Use App.RunCombined, when exact order matter more, than streams
+// distinction. Safe for concurrent use.
+type MergedCapture struct {
+	mutex  sync.Mutex
+	chunks []OutputChunk
+}
+
+// NewMergedCapture create empty capture, which writers
+// can be passed to Start or Run.
+func NewMergedCapture() *MergedCapture {
+	return &MergedCapture{}
+}
+
+// Stdout return writer, which record stdout stream.
+func (c *MergedCapture) Stdout() io.Writer {
+	return &mergedWriter{c: c, stream: "stdout"}
+}
+
+// Stderr return writer, which record stderr stream.
+func (c *MergedCapture) Stderr() io.Writer {
+	return &mergedWriter{c: c, stream: "stderr"}
+}
+
+// Chunks return output recorded in the order received.
+func (c *MergedCapture) Chunks() []OutputChunk {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]OutputChunk{}, c.chunks...)
+}
+
+// Bytes return both streams merged.
+func (c *MergedCapture) Bytes() []byte {
+	return c.stream("")
+}
+
+// String return both streams merged.
+func (c *MergedCapture) String() string {
+	return string(c.Bytes())
+}
+
+// StdoutBytes return stdout only.
+func (c *MergedCapture) StdoutBytes() []byte {
+	return c.stream("stdout")
+}
+
+// StderrBytes return stderr only.
+func (c *MergedCapture) StderrBytes() []byte {
+	return c.stream("stderr")
+}
+
+// stream concatenate chunks of the stream, or all of them, if empty.
+func (c *MergedCapture) stream(name string) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var b bytes.Buffer
+	for _, chunk := range c.chunks {
+		if name == "" || chunk.Stream == name {
+			b.Write(chunk.Data)
+		}
+	}
+	return b.Bytes()
+}
+
+type mergedWriter struct {
+	c      *MergedCapture
+	stream string
+}
+
+func (w *mergedWriter) Write(p []byte) (int, error) {
+	w.c.mutex.Lock()
+	defer w.c.mutex.Unlock()
+	w.c.chunks = append(w.c.chunks, OutputChunk{Stream: w.stream,
+		Data: append([]byte{}, p...), Time: time.Now()})
+	return len(p), nil
+}
+
+// RunMerged run application synchronously, recording
+// both streams with MergedCapture.
+func (app *App) RunMerged(stdin io.Reader) (*MergedCapture, ExitCodeOrError) {
+	c := NewMergedCapture()
+	st := app.Run(stdin, c.Stdout(), c.Stderr())
+	return c, st
+}
+
+// RunCombined run application synchronously, capturing stdout
+// and stderr combined, as shell "2>&1" does. Both streams of
+// application share single pipe, so order of writes is exact,
+// unless tees or output filters are configured.
+func (app *App) RunCombined(stdin io.Reader) ([]byte, ExitCodeOrError) {
+	var b bytes.Buffer
+	st := app.Run(stdin, &b, &b)
+	return b.Bytes(), st
+}
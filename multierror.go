@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommandError describe failure of single application run by
+// orchestration helpers (RunSequence, RunParallel, Group): failure to
+// start or exit with error, either non-zero exit code. errors.Is and
+// errors.As look into error of exit status, if any.
+type CommandError struct {
+	// Index is a position of application in the list.
+	Index  int
+	App    *App
+	Status ExitCodeOrError
+	// kind name application in messages ("step", "app").
+	kind string
+}
+
+func (e *CommandError) Error() string {
+	if e.Status.Error != nil {
+		return fmt.Sprintf("%s %d (%s): %v", e.kind, e.Index+1, e.App.Name(), e.Status.Error)
+	}
+	return fmt.Sprintf("%s %d (%s): exit code %d", e.kind, e.Index+1, e.App.Name(),
+		e.Status.ExitCode)
+}
+
+// Unwrap return error of exit status, nil if application
+// exited with non-zero exit code.
+func (e *CommandError) Unwrap() error {
+	return e.Status.Error
+}
+
+// MultiError aggregate failures of applications run by orchestration
+// helpers. errors.Is and errors.As match any member, so
+// errors.As(err, &cmdErr) return the first CommandError.
+type MultiError struct {
+	// Errors list failed applications in order of the list.
+	Errors []*CommandError
+	// Total is a number of applications run (or to be run).
+	Total int
+}
+
+// Error render summary: the single failure as is,
+// otherwise count of failures followed by every one.
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	list := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		list[i] = err.Error()
+	}
+	return fmt.Sprintf("%d of %d failed: %s", len(e.Errors), e.Total,
+		strings.Join(list, "; "))
+}
+
+// Unwrap return members, so errors.Is and errors.As inspect them.
+func (e *MultiError) Unwrap() []error {
+	list := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		list[i] = err
+	}
+	return list
+}
+
+// add record failure of application.
+func (e *MultiError) add(kind string, i int, app *App, st ExitCodeOrError) *CommandError {
+	err := &CommandError{Index: i, App: app, Status: st, kind: kind}
+	e.Errors = append(e.Errors, err)
+	return err
+}
+
+// errOrNil return nil, if no failures recorded, to avoid
+// non-nil error interface holding nil pointer.
+func (e *MultiError) errOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
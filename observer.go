@@ -0,0 +1,57 @@
+package shell
+
+import (
+	"sync"
+	"time"
+)
+
+// Observer receive notifications about lifecycle of every
+// application started by the package, which is used to plug
+// in metrics, tracing and logging. Methods must not block,
+// but may call AddObserver and RemoveObserver.
+type Observer interface {
+	// Started is called once application has been started.
+	Started(app *App)
+	// Failed is called when application failed to start.
+	Failed(app *App, err error)
+	// Exited is called once started application has finished.
+	Exited(app *App, st ExitCodeOrError, duration time.Duration)
+	// Killed is called once application has been killed with Kill call.
+	Killed(app *App)
+}
+
+var (
+	observersMutex sync.RWMutex
+	observers      []Observer
+)
+
+// AddObserver register observer to receive notifications.
+func AddObserver(o Observer) {
+	observersMutex.Lock()
+	defer observersMutex.Unlock()
+	observers = append(observers, o)
+}
+
+// RemoveObserver unregister observer added with AddObserver.
+func RemoveObserver(o Observer) {
+	observersMutex.Lock()
+	defer observersMutex.Unlock()
+	for i, item := range observers {
+		if item == o {
+			observers = append(observers[:i:i], observers[i+1:]...)
+			break
+		}
+	}
+}
+
+func notify(f func(o Observer)) {
+	// Observers are called without lock held, so they can add
+	// and remove observers, themselves included. Elements of the
+	// slice are never modified in place, so it can be shared.
+	observersMutex.RLock()
+	list := observers
+	observersMutex.RUnlock()
+	for _, o := range list {
+		f(o)
+	}
+}
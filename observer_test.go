@@ -0,0 +1,47 @@
+//go:build !windows
+
+package shell
+
+import (
+	"testing"
+	"time"
+)
+
+// selfRemovingObserver unregister itself, once application exited.
+type selfRemovingObserver struct {
+	exited chan struct{}
+}
+
+func (o *selfRemovingObserver) Started(app *App)           {}
+func (o *selfRemovingObserver) Failed(app *App, err error) {}
+func (o *selfRemovingObserver) Killed(app *App)            {}
+
+func (o *selfRemovingObserver) Exited(app *App, st ExitCodeOrError, duration time.Duration) {
+	RemoveObserver(o)
+	AddObserver(nopObserver{})
+	RemoveObserver(nopObserver{})
+	close(o.exited)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) Started(app *App)                                            {}
+func (nopObserver) Failed(app *App, err error)                                  {}
+func (nopObserver) Exited(app *App, st ExitCodeOrError, duration time.Duration) {}
+func (nopObserver) Killed(app *App)                                             {}
+
+func TestObserverRemoveItself(t *testing.T) {
+	o := &selfRemovingObserver{exited: make(chan struct{})}
+	AddObserver(o)
+	done := make(chan struct{})
+	go func() {
+		NewApp("true").Run(nil, nil, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("observer changing observers deadlocked")
+	}
+	<-o.exited
+}
@@ -0,0 +1,44 @@
+package shell
+
+// Option configure application, see App.With.
+type Option func(app *App)
+
+// With apply options to application and return it,
+// so call can be chained with NewApp:
+//
+//	app := shell.NewApp("ls", "-l").With(shell.WithStripANSI())
+func (app *App) With(options ...Option) *App {
+	for _, option := range options {
+		option(app)
+	}
+	return app
+}
+
+// setError keep the first error of options applied,
+// which is reported by Start, since options can't return errors.
+func (app *App) setError(err error) {
+	if app.err == nil {
+		app.err = err
+	}
+}
+
+// WithoutProcessGroup start application in the process group of the
+// calling process, instead of the new one, which is necessary for
+// applications relying on terminal job control. Kill then terminate
+// only application process itself, but not its children.
+func WithoutProcessGroup() Option {
+	return func(app *App) {
+		setProcessGroup(app.SysProcAttr(), false)
+	}
+}
+
+// WithNewSession start application in the new session (setsid),
+// detaching it from controlling terminal of the calling process,
+// so application doesn't receive terminal signals, like SIGINT
+// or SIGHUP. Application become leader of the new process group
+// as well, thus Kill still terminate all its children.
+func WithNewSession() Option {
+	return func(app *App) {
+		setNewSession(app.SysProcAttr())
+	}
+}
@@ -0,0 +1,165 @@
+package shell
+
+import (
+	"io"
+	"reflect"
+	"sync"
+)
+
+// TeeStdout add writers, which receive application stdout
+// in addition to the writer passed to Run/Start call.
+// Writers shared between stdout and stderr are synchronized,
+// so the same buffer or file can be passed to both.
+func (app *App) TeeStdout(writers ...io.Writer) {
+	app.stdoutTee = append(app.stdoutTee, writers...)
+}
+
+// TeeStderr add writers, which receive application stderr
+// in addition to the writer passed to Run/Start call.
+func (app *App) TeeStderr(writers ...io.Writer) {
+	app.stderrTee = append(app.stderrTee, writers...)
+}
+
+// runTees return tee lists extended with writers created for
+// the run by options (tail buffer, gzip capture and so on).
+func (app *App) runTees() ([]io.Writer, []io.Writer, error) {
+	stdoutTee := append([]io.Writer{}, app.stdoutTee...)
+	stderrTee := append([]io.Writer{}, app.stderrTee...)
+	for _, create := range []func() (io.Writer, io.Writer, error){
+		app.tailWriters,
+		app.gzipWriters,
+		app.progressWriters,
+		app.unixSocketWriters,
+		app.respondWriters,
+		app.heartbeatWriters,
+		app.broadcastWriters,
+		app.timestampWriters,
+	} {
+		out, err, e := create()
+		if e != nil {
+			return nil, nil, e
+		}
+		if out != nil {
+			stdoutTee = append(stdoutTee, out)
+		}
+		if err != nil {
+			stderrTee = append(stderrTee, err)
+		}
+	}
+	return stdoutTee, stderrTee, nil
+}
+
+// lockedWriter serialize calls to the writer shared between streams.
+type lockedWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.w.Write(p)
+}
+
+// writerLocks keep single lockedWriter per distinct writer.
+type writerLocks struct {
+	list []*lockedWriter
+}
+
+func (l *writerLocks) get(w io.Writer) io.Writer {
+	if !reflect.TypeOf(w).Comparable() {
+		// Can't detect sharing, so lock individually.
+		return &lockedWriter{w: w}
+	}
+	for _, item := range l.list {
+		if item.w == w {
+			return item
+		}
+	}
+	item := &lockedWriter{w: w}
+	l.list = append(l.list, item)
+	return item
+}
+
+// composeStreams compose destination writers of stdout and stderr
+// from the writers passed to Run/Start (might be nil) and tees.
+// Return nil for the stream without destination at all.
+func composeStreams(stdout, stderr io.Writer,
+	stdoutTee, stderrTee []io.Writer) (io.Writer, io.Writer) {
+
+	if len(stdoutTee) == 0 && len(stderrTee) == 0 {
+		// Nothing to share, exec.Cmd synchronize writes itself,
+		// if the same writer is passed to both streams.
+		return stdout, stderr
+	}
+	locks := &writerLocks{}
+	compose := func(w io.Writer, tees []io.Writer) io.Writer {
+		var list []io.Writer
+		if w != nil {
+			list = append(list, locks.get(w))
+		}
+		for _, tee := range tees {
+			list = append(list, locks.get(tee))
+		}
+		switch len(list) {
+		case 0:
+			return nil
+		case 1:
+			return list[0]
+		default:
+			return io.MultiWriter(list...)
+		}
+	}
+	return compose(stdout, stdoutTee), compose(stderr, stderrTee)
+}
+
+// flusher is implemented by output filters, which
+// buffer data and need to flush it once application exits.
+type flusher interface {
+	Flush() error
+}
+
+// outputFilters return filters applied to both output streams
+// in the order of wrapping, from the destination outward,
+// so the last filter receive raw output first.
+func (app *App) outputFilters() []func(w io.Writer, stream string) io.Writer {
+	return []func(w io.Writer, stream string) io.Writer{
+		app.rateFilter,
+		app.limitFilter,
+		app.prefixFilter,
+		app.redactFilter,
+		app.stripANSIFilter,
+		app.encodingFilter,
+	}
+}
+
+// filterStream wrap stream destination with output filters,
+// keeping those, which should be flushed on exit.
+func (app *App) filterStream(w io.Writer, stream string) io.Writer {
+	if w == nil {
+		return nil
+	}
+	for _, filter := range app.outputFilters() {
+		w = filter(w, stream)
+		if f, ok := w.(flusher); ok {
+			app.flushers = append(app.flushers, f)
+		}
+	}
+	return w
+}
+
+// flushStreams flush output filters in the reverse order of wrapping,
+// so data buffered by outer filter pass through inner ones.
+func (app *App) flushStreams() {
+	for i := len(app.flushers) - 1; i >= 0; i-- {
+		app.flushers[i].Flush()
+	}
+}
+
+// closeAll close resources opened for the run.
+func (app *App) closeAll() {
+	for _, c := range app.closers {
+		c.Close()
+	}
+	app.closers = nil
+}
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	extraPathMutex sync.RWMutex
+	extraPath      []string
+)
+
+// SetExtraPath set directories prepended to PATH for every application,
+// after ones specified with WithExtraPath, see WithExtraPath.
+func SetExtraPath(dirs ...string) {
+	extraPathMutex.Lock()
+	defer extraPathMutex.Unlock()
+	extraPath = append([]string{}, dirs...)
+}
+
+// WithExtraPath prepend directories to PATH, which is used to find
+// executable specified by name on start and passed to application
+// environment, useful for tools bundled with the calling application.
+// Applies to local executor only.
+func WithExtraPath(dirs ...string) Option {
+	return func(app *App) {
+		app.extraPath = append(app.extraPath, dirs...)
+	}
+}
+
+// extraDirs return directories of application and package default.
+func (app *App) extraDirs() []string {
+	if app.executor != LocalExecutor {
+		return nil
+	}
+	extraPathMutex.RLock()
+	defer extraPathMutex.RUnlock()
+	if len(app.extraPath) == 0 && len(extraPath) == 0 {
+		return nil
+	}
+	return append(append([]string{}, app.extraPath...), extraPath...)
+}
+
+// lookExtraPath resolve executable name against extra directories,
+// which take precedence over PATH.
+func (app *App) lookExtraPath() {
+	dirs := app.extraDirs()
+	name := app.cmd.Args[0]
+	if len(dirs) == 0 || strings.ContainsRune(name, filepath.Separator) {
+		return
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() && fi.Mode()&0111 != 0 {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			app.cmd.Path = path
+			app.cmd.Err = nil
+			return
+		}
+	}
+}
+
+// extraPathCmd return copy of cmd with extra directories
+// prepended to PATH environment.
+func (app *App) extraPathCmd(cmd *exec.Cmd) *exec.Cmd {
+	dirs := app.extraDirs()
+	if len(dirs) == 0 {
+		return cmd
+	}
+	c := *cmd
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	var orig string
+	for _, item := range base {
+		if strings.HasPrefix(item, "PATH=") {
+			orig = item[len("PATH="):]
+		}
+	}
+	path := strings.Join(dirs, string(os.PathListSeparator))
+	if orig != "" {
+		path += string(os.PathListSeparator) + orig
+	}
+	// The last entry win, when duplicated.
+	c.Env = append(append([]string{}, base...), "PATH="+path)
+	return &c
+}
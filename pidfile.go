@@ -0,0 +1,137 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// WithPidFile write application pid to the file at path once started
+// and remove it, when application exits. Start fails, if file exists
+// and refer to the process alive, stale file is overwritten.
+// With StartDetached file is written, but not removed.
+// Supported by local executor only.
+func WithPidFile(path string) Option {
+	return func(app *App) {
+		app.pidFile = path
+	}
+}
+
+// checkPidFile verify, that pid file doesn't refer to the process alive.
+func (app *App) checkPidFile() error {
+	if app.pidFile == "" {
+		return nil
+	}
+	if app.executor != LocalExecutor {
+		return errors.New("pid file is supported by local executor only")
+	}
+	p, err := FromPidFile(app.pidFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil && p.Alive() {
+		return fmt.Errorf("process %d from pid file %q is running", p.Pid(), app.pidFile)
+	}
+	// Stale or broken file, which is overwritten.
+	return nil
+}
+
+// writePidFile write pid file atomically, registering it
+// to remove once application exits, if remove is true.
+func (app *App) writePidFile(pid int, remove bool) error {
+	if app.pidFile == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(app.pidFile), ".pid")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(strconv.Itoa(pid) + "\n")
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), app.pidFile)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if remove {
+		app.closers = append(app.closers, pidFileRemover{path: app.pidFile, pid: pid})
+	}
+	return nil
+}
+
+// pidFileRemover remove pid file, unless
+// it was overwritten by another process.
+type pidFileRemover struct {
+	path string
+	pid  int
+}
+
+func (r pidFileRemover) Close() error {
+	p, err := FromPidFile(r.path)
+	if err != nil || p.Pid() != r.pid {
+		return nil
+	}
+	return os.Remove(r.path)
+}
+
+// PidProcess is a process, identified by pid file,
+// which might be started by another process.
+type PidProcess struct {
+	pid int
+}
+
+// FromPidFile read pid file written by application started
+// with WithPidFile option, and return process, which can be
+// signaled or killed.
+func FromPidFile(path string) (*PidProcess, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return nil, fmt.Errorf("pid file %q is broken", path)
+	}
+	return &PidProcess{pid: pid}, nil
+}
+
+// Pid return process identifier.
+func (p *PidProcess) Pid() int {
+	return p.pid
+}
+
+// Alive check if process is still running.
+func (p *PidProcess) Alive() bool {
+	err := signalPid(p.pid, 0)
+	// EPERM mean, that process exists, but is owned by another user.
+	return err == nil || err == syscall.EPERM
+}
+
+// Signal send signal to the process.
+func (p *PidProcess) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	return signalPid(p.pid, s)
+}
+
+// Kill terminate process immediately, with child processes, if process
+// is a leader of process group (which is the default for App).
+func (p *PidProcess) Kill() error {
+	if pgid, err := getpgid(p.pid, nil); err == nil && pgid == p.pid {
+		return signalGroup(pgid, syscall.SIGKILL)
+	}
+	return signalPid(p.pid, syscall.SIGKILL)
+}
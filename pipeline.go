@@ -0,0 +1,364 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Pipeline connect applications the same way shell does with "|":
+// stdout of every application is passed to stdin of the next one.
+// Applications are connected with OS pipes, which descriptors
+// are inherited directly, so data doesn't pass through the Go process
+// (unless application has tees or output filters configured, or it's
+// run by remote backend). Stderr of all applications go to the same
+// writer. Stream can be split with Tee stage: on Linux it's copied
+// with tee(2) and splice(2), if all destinations are files (other than
+// one are pipes), otherwise data goes through the process.
+type Pipeline struct {
+	apps     []*App
+	tees     map[int][]interface{}
+	branches []*App
+	err      error
+	mutex    sync.Mutex
+	statuses []ExitCodeOrError
+	teeErr   error
+}
+
+// NewPipeline create pipeline of applications, in the order data flow.
+func NewPipeline(apps ...*App) *Pipeline {
+	return &Pipeline{apps: apps, tees: make(map[int][]interface{})}
+}
+
+// Pipe append applications to the pipeline.
+func (p *Pipeline) Pipe(apps ...*App) *Pipeline {
+	p.apps = append(p.apps, apps...)
+	return p
+}
+
+// Tee split output of the last application added to the pipeline,
+// as tee utility does: besides the next application (or pipeline stdout,
+// if it's the last one), data is copied to every branch, which is either
+// *App (run with copy as stdin, its stdout discarded, unless configured
+// otherwise, and stderr going to pipeline stderr), or io.Writer:
+//
+//	p := shell.NewPipeline(producer).Tee(consumer1, file).Pipe(consumer2)
+//
+// Branch, which failed to accept data (application exited, writer
+// returned error), is dropped, while others keep receiving data.
+// Every destination get EOF, once source application output finished.
+func (p *Pipeline) Tee(branches ...interface{}) *Pipeline {
+	if len(p.apps) == 0 {
+		p.err = errors.New("tee can't be the first stage of pipeline")
+		return p
+	}
+	for _, branch := range branches {
+		switch b := branch.(type) {
+		case *App:
+			p.branches = append(p.branches, b)
+		case io.Writer:
+		default:
+			p.err = fmt.Errorf("tee branch of type %T is neither *App nor io.Writer", b)
+			return p
+		}
+	}
+	i := len(p.apps) - 1
+	p.tees[i] = append(p.tees[i], branches...)
+	return p
+}
+
+// Apps return applications of the pipeline,
+// followed by applications of tee branches.
+func (p *Pipeline) Apps() []*App {
+	return append(append([]*App{}, p.apps...), p.branches...)
+}
+
+// Start run all applications of the pipeline asynchronously and
+// return channel to wait for exit status of the pipeline, which
+// is the status of the last application, unless some application
+// failed with error. If any application failed to run, the ones
+// started already are killed and error returned.
+func (p *Pipeline) Start(stdin io.Reader, stdout, stderr io.Writer) (chan ExitCodeOrError, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.apps) == 0 {
+		return nil, errors.New("pipeline is empty")
+	}
+	if stderr != nil {
+		if _, ok := stderr.(*os.File); !ok {
+			stderr = &lockedWriter{w: stderr}
+		}
+	}
+	var copiers []*teeCopier
+	in := stdin
+	for i, app := range p.apps {
+		var out io.Writer = stdout
+		var r, w *os.File
+		if i < len(p.apps)-1 {
+			var err error
+			r, w, err = os.Pipe()
+			if err != nil {
+				p.abort(i, in, copiers)
+				return nil, err
+			}
+			out = w
+		}
+		var tee *teeCopier
+		if branches, ok := p.tees[i]; ok {
+			var err error
+			tee, err = p.startTee(out, w != nil, branches, stderr)
+			if err != nil {
+				if r != nil {
+					r.Close()
+					w.Close()
+				}
+				p.abort(i, in, copiers)
+				return nil, err
+			}
+			copiers = append(copiers, tee)
+			out = tee.w
+		}
+		_, err := app.Start(in, out, stderr)
+		pw := w
+		if tee != nil {
+			pw = tee.w
+		}
+		if pw != nil {
+			if err == nil && app.cmd.Stdout != pw {
+				// Output is copied through goroutine (tees, filters),
+				// so pipe must be kept open, until application exits.
+				go func(app *App, pw *os.File) {
+					app.wait()
+					pw.Close()
+				}(app, pw)
+			} else {
+				// Descriptor is inherited by the process now.
+				pw.Close()
+			}
+		}
+		if f, ok := in.(*os.File); ok && i > 0 {
+			f.Close()
+		}
+		if err != nil {
+			if r != nil {
+				r.Close()
+			}
+			if tee != nil {
+				// Copier release branches and next stage on EOF.
+				copiers = copiers[:len(copiers)-1]
+				tee.wait()
+			}
+			p.abort(i, nil, copiers)
+			return nil, err
+		}
+		if tee != nil {
+			go tee.run()
+		}
+		in = r
+	}
+	ch := make(chan ExitCodeOrError, 1)
+	go p.wait(ch, copiers)
+	return ch, nil
+}
+
+// startTee create pipe source application write to, and start
+// branch applications reading copies of data.
+func (p *Pipeline) startTee(next io.Writer, ownNext bool, branches []interface{},
+	stderr io.Writer) (*teeCopier, error) {
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	tee := &teeCopier{r: r, w: w, done: make(chan struct{})}
+	if next != nil {
+		tee.dests = append(tee.dests, teeDest{w: next})
+	}
+	if ownNext {
+		// Pipe to the next stage is owned by copier.
+		tee.closers = append(tee.closers, next.(io.Closer))
+	}
+	for _, branch := range branches {
+		app, ok := branch.(*App)
+		if !ok {
+			tee.dests = append(tee.dests, teeDest{w: branch.(io.Writer), report: true})
+			continue
+		}
+		br, bw, err := os.Pipe()
+		if err == nil {
+			_, err = app.Start(br, nil, stderr)
+			br.Close()
+		}
+		if err != nil {
+			if bw != nil {
+				bw.Close()
+			}
+			r.Close()
+			w.Close()
+			tee.closeAll()
+			for _, started := range tee.apps {
+				started.Kill()
+			}
+			return nil, err
+		}
+		tee.apps = append(tee.apps, app)
+		tee.dests = append(tee.dests, teeDest{w: bw})
+		tee.closers = append(tee.closers, bw)
+	}
+	return tee, nil
+}
+
+// teeDest is a destination of tee; failure of applications
+// is reported by their own exit status, while failure of writers
+// is reported as pipeline error.
+type teeDest struct {
+	w      io.Writer
+	report bool
+}
+
+// teeCopier copy output of application to the next stage and branches.
+type teeCopier struct {
+	r, w    *os.File
+	dests   []teeDest
+	closers []io.Closer
+	apps    []*App
+	err     error
+	done    chan struct{}
+}
+
+func (t *teeCopier) run() {
+	defer close(t.done)
+	defer t.closeAll()
+	defer t.r.Close()
+	dests := append([]teeDest{}, t.dests...)
+	if t.spliceTee(dests) {
+		return
+	}
+	buf := make([]byte, 32*1024)
+	for len(dests) > 0 {
+		n, err := t.r.Read(buf)
+		if n > 0 {
+			alive := dests[:0]
+			for _, dest := range dests {
+				if _, werr := dest.w.Write(buf[:n]); werr != nil {
+					if dest.report && t.err == nil {
+						t.err = fmt.Errorf("tee: %v", werr)
+					}
+					continue
+				}
+				alive = append(alive, dest)
+			}
+			dests = alive
+		}
+		if err != nil {
+			return
+		}
+	}
+	// Nobody read data anymore: closing pipe (on return)
+	// make source application receive SIGPIPE, as shell does.
+}
+
+// wait run copier, if not running yet, and wait it finished.
+func (t *teeCopier) wait() {
+	go t.run()
+	<-t.done
+}
+
+func (t *teeCopier) closeAll() {
+	for _, c := range t.closers {
+		c.Close()
+	}
+}
+
+// abort kill started applications (including tee branches)
+// and close reader to be passed to the next one.
+func (p *Pipeline) abort(started int, in io.Reader, copiers []*teeCopier) {
+	if f, ok := in.(*os.File); ok && started > 0 {
+		f.Close()
+	}
+	for _, app := range p.apps[:started] {
+		app.Kill()
+	}
+	for _, tee := range copiers {
+		<-tee.done
+		for _, app := range tee.apps {
+			app.Kill()
+		}
+	}
+}
+
+func (p *Pipeline) wait(ch chan ExitCodeOrError, copiers []*teeCopier) {
+	apps := p.apps
+	for _, tee := range copiers {
+		apps = append(apps, tee.apps...)
+	}
+	statuses := make([]ExitCodeOrError, len(apps))
+	for i, app := range apps {
+		statuses[i] = app.wait()
+	}
+	var teeErr error
+	for _, tee := range copiers {
+		<-tee.done
+		if tee.err != nil && teeErr == nil {
+			teeErr = tee.err
+		}
+	}
+	p.mutex.Lock()
+	p.statuses = statuses
+	p.teeErr = teeErr
+	p.mutex.Unlock()
+	st := statuses[len(p.apps)-1]
+	for _, item := range statuses {
+		if item.Error != nil {
+			st = item
+			break
+		}
+	}
+	if st.Error == nil && teeErr != nil {
+		st = ExitCodeOrError{ExitCode: st.ExitCode, Error: teeErr}
+	}
+	ch <- st
+}
+
+// Run run pipeline synchronously, see Start.
+func (p *Pipeline) Run(stdin io.Reader, stdout, stderr io.Writer) ExitCodeOrError {
+	ch, err := p.Start(stdin, stdout, stderr)
+	if err != nil {
+		return ExitCodeOrError{0, err}
+	}
+	return <-ch
+}
+
+// Statuses return exit statuses of all applications (in the order
+// of Apps) once pipeline has finished (like PIPESTATUS in bash),
+// or nil otherwise.
+func (p *Pipeline) Statuses() []ExitCodeOrError {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.statuses
+}
+
+// Kill terminate all running applications of the pipeline.
+func (p *Pipeline) Kill() error {
+	var first error
+	for _, app := range p.Apps() {
+		if app.getProcess() == nil {
+			continue
+		}
+		// Stage might have already exited, for instance,
+		// the one with no input to wait for.
+		select {
+		case <-app.done:
+			continue
+		default:
+		}
+		err := app.kill()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
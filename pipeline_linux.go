@@ -0,0 +1,189 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// spliceChunk limit data passed by single tee(2) or splice(2) call.
+const spliceChunk = 1 << 20
+
+// spliceTee copy output of application inside the kernel, so data doesn't
+// pass through the process: tee(2) duplicate it to every destination but
+// the last one, while splice(2) move it to the last one. It's possible only
+// if all destinations are files, and all of them, except one, are pipes.
+// Return false otherwise, so data should be copied by the caller.
+func (t *teeCopier) spliceTee(dests []teeDest) bool {
+	files := make([]*os.File, len(dests))
+	other := -1
+	for i, dest := range dests {
+		f, ok := dest.w.(*os.File)
+		if !ok {
+			return false
+		}
+		if !isPipe(f) {
+			if other >= 0 {
+				return false
+			}
+			other = i
+		}
+		files[i] = f
+	}
+	last := len(dests) - 1
+	if other >= 0 {
+		// Data can be only moved to the file, which isn't a pipe.
+		dests[other], dests[last] = dests[last], dests[other]
+		files[other], files[last] = files[last], files[other]
+	}
+	// Descriptors are switched to blocking mode, so calls below
+	// wait for data and room in destination pipes.
+	rfd := int(t.r.Fd())
+	fds := make([]int, len(files))
+	for i, f := range files {
+		fds[i] = int(f.Fd())
+	}
+	var buf []byte
+	copyLast := false
+	for len(dests) > 0 {
+		last = len(dests) - 1
+		errs := make([]error, len(dests))
+		sent := make([]int64, len(dests))
+		// Size of data is defined by the first successful tee(2),
+		// so every destination receive the same bytes.
+		n := int64(-1)
+		short := false
+		for i := 0; i < last; i++ {
+			size := n
+			if size < 0 {
+				size = spliceChunk
+			}
+			m, err := retryEINTR(func() (int64, error) {
+				return unix.Tee(rfd, fds[i], int(size), 0)
+			})
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			if n < 0 {
+				if m == 0 {
+					// Source application output finished.
+					return true
+				}
+				n = m
+			}
+			sent[i] = m
+			if m < n {
+				short = true
+			}
+		}
+		if !short && !copyLast {
+			if n < 0 {
+				// Nothing to duplicate data to, so it's just moved.
+				m, err := retryEINTR(func() (int64, error) {
+					return unix.Splice(rfd, nil, fds[last], nil, spliceChunk, unix.SPLICE_F_MOVE)
+				})
+				if err == nil && m == 0 {
+					return true
+				}
+				if err == unix.EINVAL {
+					// Destination doesn't support splice (e.g., opened with O_APPEND).
+					copyLast = true
+					continue
+				}
+				errs[last] = err
+			} else {
+				var moved int64
+				for moved < n {
+					m, err := retryEINTR(func() (int64, error) {
+						return unix.Splice(rfd, nil, fds[last], nil, int(n-moved), unix.SPLICE_F_MOVE)
+					})
+					if err != nil {
+						errs[last] = err
+						break
+					}
+					moved += m
+				}
+				if errs[last] == unix.EINVAL && moved == 0 {
+					copyLast = true
+					errs[last] = nil
+				} else if moved < n {
+					// Drop data, which is duplicated to other destinations already.
+					if _, err := io.CopyN(io.Discard, t.r, n-moved); err != nil {
+						return true
+					}
+				} else {
+					sent[last] = n
+				}
+			}
+		}
+		if short || (copyLast && sent[last] == 0) {
+			// Destination accepted part of data only, while
+			// tee(2) can't skip data sent already, so remainder
+			// is read out and written by the process.
+			size := n
+			if size < 0 {
+				size = spliceChunk
+			}
+			if int64(len(buf)) < size {
+				buf = make([]byte, size)
+			}
+			m, err := io.ReadAtLeast(t.r, buf[:size], 1)
+			if n < 0 {
+				if err != nil {
+					return true
+				}
+				n = int64(m)
+			} else if int64(m) < n {
+				if _, err = io.ReadFull(t.r, buf[m:n]); err != nil {
+					return true
+				}
+			}
+			for i := range dests {
+				if errs[i] != nil || sent[i] >= n {
+					continue
+				}
+				if _, err := files[i].Write(buf[sent[i]:n]); err != nil {
+					errs[i] = err
+				}
+			}
+		}
+		alive := 0
+		for i, dest := range dests {
+			if errs[i] != nil {
+				if dest.report && t.err == nil {
+					t.err = fmt.Errorf("tee: %v", errs[i])
+				}
+				continue
+			}
+			dests[alive], files[alive], fds[alive] = dest, files[i], fds[i]
+			alive++
+		}
+		if errs[last] != nil {
+			// Last destination is dropped, so its successor must be a pipe.
+			copyLast = false
+		}
+		dests, files, fds = dests[:alive], files[:alive], fds[:alive]
+	}
+	// Nobody read data anymore: closing pipe make
+	// source application receive SIGPIPE, as shell does.
+	return true
+}
+
+// isPipe report, whether file is a pipe.
+func isPipe(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// retryEINTR repeat system call interrupted by signal.
+func retryEINTR(call func() (int64, error)) (int64, error) {
+	for {
+		n, err := call()
+		if err != unix.EINTR {
+			return n, err
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package shell
+
+// spliceTee is supported on Linux only, so data is
+// copied by the caller.
+func (t *teeCopier) spliceTee(dests []teeDest) bool {
+	return false
+}
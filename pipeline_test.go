@@ -0,0 +1,111 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun(t *testing.T) {
+	var stdout bytes.Buffer
+	p := NewPipeline(NewApp("printf", `b\na\nc\n`), NewApp("sort"), NewApp("tr", "a-z", "A-Z"))
+	st := p.Run(nil, &stdout, nil)
+	if st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if stdout.String() != "A\nB\nC\n" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestPipelineStatuses(t *testing.T) {
+	p := NewPipeline(NewApp("sh", "-c", "exit 2"), NewApp("cat"))
+	st := p.Run(nil, nil, nil)
+	if st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	statuses := p.Statuses()
+	if len(statuses) != 2 || statuses[0].ExitCode != 2 || statuses[1].ExitCode != 0 {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+}
+
+func TestPipelineTee(t *testing.T) {
+	var stdout, branch bytes.Buffer
+	p := NewPipeline(NewApp("echo", "data")).Tee(&branch).Pipe(NewApp("cat"))
+	if st := p.Run(nil, &stdout, nil); st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if stdout.String() != "data\n" || branch.String() != "data\n" {
+		t.Fatalf("unexpected output %q and branch %q", stdout.String(), branch.String())
+	}
+}
+
+func TestPipelineTeeFiles(t *testing.T) {
+	dir := t.TempDir()
+	file, err := os.Create(filepath.Join(dir, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// Slow branch make tee(2) accept data partially.
+	branch := NewApp("sh", "-c", "sleep 0.2; cat").
+		With(WithStdoutFile(filepath.Join(dir, "branch"), false, 0644))
+	var stdout bytes.Buffer
+	p := NewPipeline(NewApp("sh", "-c", "yes 0123456789 | head -c 3000000")).
+		Tee(file, branch).Pipe(NewApp("wc", "-c"))
+	if st := p.Run(nil, &stdout, nil); st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if strings.TrimSpace(stdout.String()) != "3000000" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	want := bytes.Repeat([]byte("0123456789\n"), 3000000/11+1)[:3000000]
+	for _, name := range []string{"file", "branch"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Fatalf("%s got %d bytes, which differ from output", name, len(data))
+		}
+	}
+}
+
+func TestPipelineKillExited(t *testing.T) {
+	p := NewPipeline(NewApp("true"), NewApp("sleep", "5"))
+	ch, err := p.Start(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// Let the first stage exit.
+	for p.Apps()[0].ExitCodeOrError().Error == ErrNotFinished {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Kill(); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline is not killed")
+	}
+}
+
+func TestPipelineStartFailure(t *testing.T) {
+	p := NewPipeline(NewApp("sleep", "5"), NewApp("no-such-command-d2r2"))
+	if _, err := p.Start(nil, nil, nil); err == nil {
+		t.Fatal("Start succeeded with missing command")
+	}
+	app := p.Apps()[0]
+	if app.getProcess() != nil {
+		if st := app.Wait(); st.Error == nil && st.ExitCode == 0 {
+			t.Fatalf("started stage is not killed: %+v", st)
+		}
+	}
+}
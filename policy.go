@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Policy decide, whether command may be started: it is consulted
+// by Start (and StartDetached) of every application before anything
+// is run, and command is rejected, if policy return error.
+// Path is an absolute path of the executable resolved against PATH
+// (or the name itself for remote executors), args exclude program name.
+type Policy interface {
+	Check(path string, args []string) error
+}
+
+// PolicyFunc is an adapter to use function as Policy.
+type PolicyFunc func(path string, args []string) error
+
+// Check implement Policy interface.
+func (f PolicyFunc) Check(path string, args []string) error {
+	return f(path, args)
+}
+
+var (
+	policyMutex sync.RWMutex
+	policy      Policy
+)
+
+// SetPolicy install package-level policy consulted before every
+// Start, which is a guardrail for services executing commands
+// influenced by users. Nil remove policy.
+func SetPolicy(p Policy) {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	policy = p
+}
+
+// PolicyError is returned by Start, when command is rejected by policy.
+type PolicyError struct {
+	Path string
+	Args []string
+	Err  error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("command %q %q rejected by policy: %v", e.Path, e.Args, e.Err)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// checkPolicy consult package-level policy, if any.
+func (app *App) checkPolicy() error {
+	policyMutex.RLock()
+	p := policy
+	policyMutex.RUnlock()
+	if p == nil {
+		return nil
+	}
+	path := app.resolvedPath()
+	args := app.cmd.Args[1:]
+	if err := p.Check(path, args); err != nil {
+		return &PolicyError{Path: path, Args: args, Err: err}
+	}
+	return nil
+}
+
+// resolvedPath return absolute path of local executable, if it is
+// found, and name as specified otherwise.
+func (app *App) resolvedPath() string {
+	if app.executor != LocalExecutor {
+		// Path resolved against local PATH is meaningless for other backends.
+		return app.cmd.Args[0]
+	}
+	path := app.cmd.Path
+	if filepath.IsAbs(path) || !strings.ContainsRune(path, filepath.Separator) {
+		return path
+	}
+	if app.cmd.Dir != "" {
+		path = filepath.Join(app.cmd.Dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// AllowList is a Policy, which allow only commands added to the list,
+// rejecting any other.
+type AllowList struct {
+	sync.RWMutex
+	rules []allowRule
+}
+
+type allowRule struct {
+	path string
+	args []*regexp.Regexp
+}
+
+// NewAllowList create empty allow-list, which reject everything.
+func NewAllowList() *AllowList {
+	return &AllowList{}
+}
+
+// Add allow executable at path (name is resolved against PATH)
+// with arguments matching patterns: every pattern is a regular
+// expression, which must match whole argument at the same position,
+// and the number of arguments must be equal to the number of patterns.
+// Without patterns any arguments are allowed.
+func (l *AllowList) Add(path string, argPatterns ...string) error {
+	if !filepath.IsAbs(path) {
+		var err error
+		path, err = exec.LookPath(path)
+		if err != nil {
+			return err
+		}
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+	}
+	rule := allowRule{path: filepath.Clean(path)}
+	for _, pattern := range argPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid argument pattern %q: %v", pattern, err)
+		}
+		rule.args = append(rule.args, re)
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.rules = append(l.rules, rule)
+	return nil
+}
+
+// Check implement Policy interface.
+func (l *AllowList) Check(path string, args []string) error {
+	path = filepath.Clean(path)
+	l.RLock()
+	defer l.RUnlock()
+	found := false
+	for _, rule := range l.rules {
+		if rule.path != path {
+			continue
+		}
+		found = true
+		if rule.match(args) {
+			return nil
+		}
+	}
+	if found {
+		return fmt.Errorf("arguments of %q are not allowed", path)
+	}
+	return fmt.Errorf("executable %q is not allowed", path)
+}
+
+func (r allowRule) match(args []string) bool {
+	if len(r.args) == 0 {
+		return true
+	}
+	if len(args) != len(r.args) {
+		return false
+	}
+	for i, re := range r.args {
+		if !re.MatchString(args[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrefixWriter prefix every line written with label, so output
+// of several applications running into the same terminal remain
+// attributable. Lines are written to the destination entirely,
+// so output of different writers doesn't mix within a line.
+// Call Flush to write remaining incomplete line.
+type PrefixWriter struct {
+	sync.Mutex
+	w      io.Writer
+	prefix func() string
+	buf    []byte
+}
+
+// NewPrefixWriter create writer, which prefix lines with the label.
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: func() string { return prefix }}
+}
+
+// Write implement io.Writer interface.
+func (p *PrefixWriter) Write(data []byte) (int, error) {
+	p.Lock()
+	defer p.Unlock()
+	p.buf = append(p.buf, data...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i == -1 {
+		return len(data), nil
+	}
+	err := p.writeLines(p.buf[:i+1])
+	p.buf = append(p.buf[:0], p.buf[i+1:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (p *PrefixWriter) writeLines(lines []byte) error {
+	prefix := []byte(p.prefix())
+	var b bytes.Buffer
+	for len(lines) > 0 {
+		i := bytes.IndexByte(lines, '\n')
+		if i == -1 {
+			i = len(lines) - 1
+		}
+		b.Write(prefix)
+		b.Write(lines[:i+1])
+		lines = lines[i+1:]
+	}
+	_, err := p.w.Write(b.Bytes())
+	return err
+}
+
+// Flush write remaining incomplete line, terminating it with new line.
+func (p *PrefixWriter) Flush() error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.writeLines(append(p.buf, '\n'))
+	p.buf = p.buf[:0]
+	return err
+}
+
+// SetOutputPrefix make application prefix every line of stdout
+// and stderr with label built from format, where placeholders
+// {name}, {pid} and {stream} are replaced with application name,
+// process identifier and stream name ("stdout" or "stderr"),
+// for instance "{name}.{pid} | ". Empty format disable prefixing.
+func (app *App) SetOutputPrefix(format string) {
+	app.outputPrefix = format
+}
+
+func (app *App) prefixFilter(w io.Writer, stream string) io.Writer {
+	if app.outputPrefix == "" {
+		return w
+	}
+	return &PrefixWriter{w: w, prefix: func() string {
+		return strings.NewReplacer("{name}", app.Name(),
+			"{pid}", strconv.Itoa(app.Pid()),
+			"{stream}", stream).Replace(app.outputPrefix)
+	}}
+}
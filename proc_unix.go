@@ -0,0 +1,83 @@
+//go:build !windows
+
+package shell
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// newSysProcAttr return default process attributes: application
+// become leader of new process group, so it can be killed
+// with all child processes.
+func newSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// setProcessGroup specify, whether application become
+// leader of new process group.
+func setProcessGroup(attr *syscall.SysProcAttr, on bool) {
+	attr.Setpgid = on
+}
+
+// setNewSession start application in the new session,
+// which leader of new process group is as well.
+func setNewSession(attr *syscall.SysProcAttr) {
+	attr.Setpgid = false
+	attr.Setsid = true
+}
+
+// getpgid return process group of the process.
+func getpgid(pid int, attr *syscall.SysProcAttr) (int, error) {
+	return syscall.Getpgid(pid)
+}
+
+// signalGroup send signal to all processes of the group.
+func signalGroup(pgid int, sig syscall.Signal) error {
+	// Specifying gid with negative sign also results in the killing of child processes.
+	return syscall.Kill(-pgid, sig)
+}
+
+// signalPid send signal to the process; signal 0 check process exists.
+func signalPid(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
+
+// errLocked is returned by lockFile, if lock is held by someone else.
+var errLocked = errors.New("file is locked")
+
+// lockFile acquire exclusive lock of the file without blocking.
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errLocked
+	}
+	if err != nil {
+		return &os.PathError{Op: "flock", Path: file.Name(), Err: err}
+	}
+	return nil
+}
+
+// mkfifo create named pipe.
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
+
+// freeSpace return space available to unprivileged user
+// on the file system path belong to.
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+	// stat.Bavail type is differs on Linux and FreeBSD, so
+	// this check is valid, despite warning about non-negative UINT64.
+	// So, please, ignore linter warning here.
+	if stat.Bavail < 0 {
+		return 0, errors.New("can't detect free space available on the system")
+	}
+	// Available blocks * size per block = available space in bytes.
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,129 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// newSysProcAttr return default process attributes: application
+// become root of new process group, so it can receive CTRL_BREAK_EVENT
+// without the calling process (see Terminate).
+func newSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// setProcessGroup specify, whether application become
+// root of new process group.
+func setProcessGroup(attr *syscall.SysProcAttr, on bool) {
+	if on {
+		attr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+	} else {
+		attr.CreationFlags &^= syscall.CREATE_NEW_PROCESS_GROUP
+	}
+}
+
+// setNewSession detach application from the console
+// of the calling process, keeping it root of new process group.
+func setNewSession(attr *syscall.SysProcAttr) {
+	attr.CreationFlags |= windows.DETACHED_PROCESS | syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// getpgid return process group of the process: Windows can't query
+// it, but process created with CREATE_NEW_PROCESS_GROUP is the root
+// of the group, which identifier is equal to process one.
+func getpgid(pid int, attr *syscall.SysProcAttr) (int, error) {
+	if attr != nil && attr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP != 0 {
+		return pid, nil
+	}
+	return 0, errors.New("process group is not known")
+}
+
+// signalGroup deliver signal to the process group: SIGKILL terminate
+// root process of the group, while SIGTERM, SIGINT and the others
+// are translated to CTRL_BREAK_EVENT, since CTRL_C_EVENT can't be
+// sent to the process group.
+func signalGroup(pgid int, sig syscall.Signal) error {
+	if sig == syscall.SIGKILL {
+		return signalPid(pgid, sig)
+	}
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(pgid))
+}
+
+// stillActive is exit code reported for the running process.
+const stillActive = 259
+
+// signalPid emulate signal delivery: signal 0 check process is
+// running, SIGKILL terminate it; other signals are not supported.
+func signalPid(pid int, sig syscall.Signal) error {
+	switch sig {
+	case 0:
+		h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+		if err == windows.ERROR_ACCESS_DENIED {
+			// Process exist, but is owned by another user.
+			return nil
+		}
+		if err != nil {
+			return syscall.ESRCH
+		}
+		defer windows.CloseHandle(h)
+		var code uint32
+		err = windows.GetExitCodeProcess(h, &code)
+		if err != nil {
+			return err
+		}
+		if code != stillActive {
+			return syscall.ESRCH
+		}
+		return nil
+	case syscall.SIGKILL:
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			return err
+		}
+		defer p.Release()
+		return p.Kill()
+	}
+	return fmt.Errorf("signal %v is not supported on Windows", sig)
+}
+
+// errLocked is returned by lockFile, if lock is held by someone else.
+var errLocked = errors.New("file is locked")
+
+// lockFile acquire exclusive lock of the file without blocking.
+func lockFile(file *os.File) error {
+	err := windows.LockFileEx(windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, &windows.Overlapped{})
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return errLocked
+	}
+	if err != nil {
+		return &os.PathError{Op: "LockFileEx", Path: file.Name(), Err: err}
+	}
+	return nil
+}
+
+// mkfifo is not supported, since Windows named pipes
+// live in separate namespace and can't be opened as files.
+func mkfifo(path string) error {
+	return errors.New("FIFO is not supported on Windows")
+}
+
+// freeSpace return space available to the calling user
+// on the volume path belong to.
+func freeSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	err = windows.GetDiskFreeSpaceEx(p, &free, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,129 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProgressParser extract progress from segment of output, which
+// is a line terminated either with new line or carriage return
+// (used by tools redrawing progress bar in place).
+// Return false, if segment doesn't contain progress.
+type ProgressParser interface {
+	Parse(segment string) (pct float64, msg string, ok bool)
+}
+
+// ProgressParserFunc is an adapter to use function as ProgressParser.
+type ProgressParserFunc func(segment string) (pct float64, msg string, ok bool)
+
+// Parse implement ProgressParser interface.
+func (f ProgressParserFunc) Parse(segment string) (float64, string, bool) {
+	return f(segment)
+}
+
+var percentRegexp = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*%`)
+
+// PercentProgress is a parser, which find the last percent
+// value in the segment, like "45%" or "12.5 %" (rsync, curl, wget),
+// and return the whole segment as a message.
+var PercentProgress ProgressParser = ProgressParserFunc(func(segment string) (float64, string, bool) {
+	m := percentRegexp.FindAllStringSubmatch(segment, -1)
+	if m == nil {
+		return 0, "", false
+	}
+	pct, err := strconv.ParseFloat(strings.Replace(m[len(m)-1][1], ",", ".", 1), 64)
+	if err != nil || pct > 100 {
+		return 0, "", false
+	}
+	return pct, segment, true
+})
+
+var barRegexp = regexp.MustCompile(`\[([#=>*\-. ]{4,})\]`)
+
+// BarProgress is a parser, which calculate progress from the
+// filling of progress bar like "[=====>    ]" or "[###.......]",
+// falling back to percent value, if segment contain it.
+var BarProgress ProgressParser = ProgressParserFunc(func(segment string) (float64, string, bool) {
+	if pct, msg, ok := PercentProgress.Parse(segment); ok {
+		return pct, msg, true
+	}
+	m := barRegexp.FindStringSubmatch(segment)
+	if m == nil {
+		return 0, "", false
+	}
+	bar := m[1]
+	filled := len(bar) - len(strings.TrimLeft(bar, "#=>*"))
+	return float64(filled) * 100 / float64(len(bar)), segment, true
+})
+
+type progressHandler struct {
+	parser ProgressParser
+	fn     func(pct float64, msg string)
+}
+
+// OnProgress make application to parse stdout and stderr with parser
+// and call fn with progress found, so UI can show live progress of
+// tools like ffmpeg, rsync or dd. Function is called from output
+// copying routines, so it should not block for long.
+func (app *App) OnProgress(parser ProgressParser, fn func(pct float64, msg string)) {
+	app.progress = append(app.progress, progressHandler{parser: parser, fn: fn})
+}
+
+// progressWriters return writers of both streams, which
+// feed progress handlers, if any registered.
+func (app *App) progressWriters() (io.Writer, io.Writer, error) {
+	if len(app.progress) == 0 {
+		return nil, nil, nil
+	}
+	// Handlers are shared between streams, so calls are serialized.
+	mutex := &sync.Mutex{}
+	return &progressWriter{handlers: app.progress, mutex: mutex},
+		&progressWriter{handlers: app.progress, mutex: mutex}, nil
+}
+
+// progressWriter split stream into segments terminated
+// with new line or carriage return and pass them to parsers.
+type progressWriter struct {
+	handlers []progressHandler
+	mutex    *sync.Mutex
+	partial  []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	data := p
+	for {
+		i := bytes.IndexAny(data, "\r\n")
+		if i == -1 {
+			break
+		}
+		w.partial = append(w.partial, data[:i]...)
+		w.segment(string(w.partial))
+		w.partial = w.partial[:0]
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial, data...)
+	const maxSegment = 64 * 1024
+	if len(w.partial) > maxSegment {
+		// Not a progress output, don't grow infinitely.
+		w.partial = w.partial[:0]
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) segment(s string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, h := range w.handlers {
+		if pct, msg, ok := h.parser.Parse(s); ok {
+			h.fn(pct, msg)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+//go:build linux || darwin || freebsd
+
+package shell
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// StartPTY start application asynchronously with stdin, stdout and
+// stderr connected to new pseudo-terminal of cols x rows size, which
+// become controlling terminal of application, and return master side
+// of the terminal, used to read application output and write its
+// input (output is read by the package, if WithBroadcast is used);
+// caller must close it once application exits. Application
+// run in the new session, so Kill still terminate all its children.
+func (app *App) StartPTY(cols, rows int) (*os.File, chan ExitCodeOrError, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ,
+		&unix.Winsize{Col: uint16(cols), Row: uint16(rows)})
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, err
+	}
+	attr := app.SysProcAttr()
+	attr.Setpgid = false
+	attr.Setsid = true
+	attr.Setctty = true
+	// Descriptor number in the child, which is stdin.
+	attr.Ctty = 0
+	app.mutex.Lock()
+	app.ptyMaster = master
+	app.mutex.Unlock()
+	app.ptyRun = true
+	ch, err := app.Start(slave, slave, slave)
+	app.ptyRun = false
+	// Descriptor is inherited by application now.
+	slave.Close()
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, ch, nil
+}
+
+// ResizePTY change window size of pseudo-terminal application
+// is started with by StartPTY, which deliver SIGWINCH to application.
+// Method expression (*App).ResizePTY can be used as ResizeFunc.
+func (app *App) ResizePTY(cols, rows int) error {
+	app.mutex.Lock()
+	master := app.ptyMaster
+	app.mutex.Unlock()
+	if master == nil {
+		return errors.New("App is not started in pseudo-terminal")
+	}
+	return unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ,
+		&unix.Winsize{Col: uint16(cols), Row: uint16(rows)})
+}
+
+// ptyDrainTimeout limit time to read output left in pseudo-terminal
+// after application exit, since its descendants might keep terminal open.
+const ptyDrainTimeout = 200 * time.Millisecond
+
+// RunTerminal run application synchronously in pseudo-terminal proxied
+// to stdin and stdout of the calling process, which allow to wrap fully
+// interactive programs, like editors or ssh. If stdin is a terminal,
+// it's switched to raw mode (so every key, including Ctrl+C, is passed
+// to application), window size changes are propagated to application,
+// and terminal state is restored once application exits.
+// Session is recorded, if WithTranscript is set.
+func (app *App) RunTerminal() ExitCodeOrError {
+	fd := int(os.Stdin.Fd())
+	isTerm := term.IsTerminal(fd)
+	cols, rows := 80, 24
+	if isTerm {
+		if w, h, err := term.GetSize(fd); err == nil && w > 0 && h > 0 {
+			cols, rows = w, h
+		}
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return ExitCodeOrError{0, err}
+		}
+		defer term.Restore(fd, state)
+	}
+	master, ch, err := app.StartPTY(cols, rows)
+	if err != nil {
+		return ExitCodeOrError{0, err}
+	}
+	defer master.Close()
+	var conn io.ReadWriter = master
+	var transcript *Transcript
+	if app.transcript != nil {
+		transcript = NewTranscript(app.transcript, app.transcriptFormat, cols, rows)
+		conn = transcript.Wrap(master)
+	}
+	if isTerm {
+		winch := make(chan os.Signal, 1)
+		signal.Notify(winch, syscall.SIGWINCH)
+		defer signal.Stop(winch)
+		go func() {
+			for range winch {
+				if w, h, err := term.GetSize(fd); err == nil {
+					app.ResizePTY(w, h)
+					if transcript != nil {
+						transcript.Resize(w, h)
+					}
+				}
+			}
+		}()
+	}
+	stdin, err := pollableStdin()
+	if err != nil {
+		app.Kill()
+		return ExitCodeOrError{0, err}
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		io.Copy(conn, stdin)
+	}()
+	var out io.Writer = os.Stdout
+	if len(app.respond) > 0 {
+		r := newResponder(app.respond, conn)
+		defer r.Close()
+		out = io.MultiWriter(out, r)
+	}
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		io.Copy(out, conn)
+	}()
+	st := <-ch
+	select {
+	case <-copied:
+	case <-time.After(ptyDrainTimeout):
+	}
+	// Unblock stdin reading routine, so the next input
+	// of the calling process is not swallowed.
+	stdin.SetReadDeadline(time.Now())
+	wg.Wait()
+	stdin.Close()
+	syscall.SetNonblock(fd, false)
+	return st
+}
+
+// pollableStdin return duplicate of stdin in non-blocking mode,
+// which reading can be interrupted with deadline.
+func pollableStdin() (*os.File, error) {
+	fd, err := syscall.Dup(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	syscall.CloseOnExec(fd)
+	err = syscall.SetNonblock(fd, true)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "stdin"), nil
+}
@@ -0,0 +1,44 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY open new pseudo-terminal pair.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := uintptr(master.Fd())
+	var name [128]byte
+	for _, req := range []uintptr{unix.TIOCPTYGRANT, unix.TIOCPTYUNLK} {
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, req, 0); errno != 0 {
+			err = errno
+			break
+		}
+	}
+	if err == nil {
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TIOCPTYGNAME,
+			uintptr(unsafe.Pointer(&name[0])))
+		if errno != 0 {
+			err = errno
+		}
+	}
+	if err == nil {
+		n := bytes.IndexByte(name[:], 0)
+		if n == -1 {
+			n = len(name)
+		}
+		slave, err = os.OpenFile(string(name[:n]), os.O_RDWR|unix.O_NOCTTY, 0)
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
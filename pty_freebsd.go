@@ -0,0 +1,28 @@
+package shell
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY open new pseudo-terminal pair.
+func openPTY() (master, slave *os.File, err error) {
+	fd, _, errno := unix.Syscall(unix.SYS_POSIX_OPENPT,
+		uintptr(unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC), 0, 0)
+	if errno != 0 {
+		return nil, nil, os.NewSyscallError("posix_openpt", errno)
+	}
+	master = os.NewFile(fd, "/dev/ptmx")
+	n, err := unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+	if err == nil {
+		slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(n),
+			os.O_RDWR|unix.O_NOCTTY, 0)
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
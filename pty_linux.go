@@ -0,0 +1,31 @@
+package shell
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY open new pseudo-terminal pair.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := int(master.Fd())
+	err = unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0)
+	if err == nil {
+		var n uint32
+		n, err = unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+		if err == nil {
+			slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)),
+				os.O_RDWR|unix.O_NOCTTY, 0)
+		}
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
@@ -0,0 +1,68 @@
+//go:build linux || darwin || freebsd
+
+package shell
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestAutoRespondPTY(t *testing.T) {
+	app := NewApp("sh", "-c", `printf 'Continue? '; read answer; echo "got $answer"`)
+	app.AutoRespond(map[*regexp.Regexp]string{regexp.MustCompile(`Continue\? `): "yes\n"})
+	master, ch, err := app.StartPTY(80, 24)
+	if err != nil {
+		t.Fatalf("StartPTY: %v", err)
+	}
+	defer master.Close()
+	found := make(chan bool, 1)
+	go func() {
+		scanner := bufio.NewScanner(app.RespondPTY(master))
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), "got yes") {
+				found <- true
+				return
+			}
+		}
+		found <- false
+	}()
+	select {
+	case ok := <-found:
+		if !ok {
+			t.Fatal("response is not received by application")
+		}
+	case <-time.After(5 * time.Second):
+		app.Kill()
+		t.Fatal("timed out waiting for response")
+	}
+	if st := <-ch; st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+}
+
+func TestAttachWebSocketPTY(t *testing.T) {
+	app := NewApp("sh", "-c", `read answer; echo "got $answer"`).With(WithBroadcast(1024))
+	master, _, err := app.StartPTY(80, 24)
+	if err != nil {
+		t.Fatalf("StartPTY: %v", err)
+	}
+	defer master.Close()
+	ws := dialWebSocket(t, func(ws *websocket.Conn) {
+		AttachWebSocket(ws, app, (*App).ResizePTY)
+	})
+	for _, msg := range []WebSocketMessage{{Type: "resize", Cols: 100, Rows: 30},
+		{Type: "stdin", Data: []byte("yes\n")}} {
+		if err := websocket.JSON.Send(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, msg := receiveOutput(t, ws)
+	if !strings.Contains(out, "got yes") || msg.Type != "exit" || msg.Code != 0 {
+		t.Fatalf("unexpected output %q and message %+v", out, msg)
+	}
+}
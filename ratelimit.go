@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RatePolicy define what to do with output exceeding
+// rate set by WithOutputRateLimit.
+type RatePolicy int
+
+const (
+	// RateThrottle delay output, so application block writing it.
+	RateThrottle RatePolicy = iota
+	// RateSummarize discard excess output, replacing it
+	// with marker telling amount of bytes suppressed.
+	RateSummarize
+)
+
+// WithOutputRateLimit limit rate of output passed to the writers
+// of every stream, so extremely chatty application can't saturate
+// logging pipeline of the calling process. Burst up to one second
+// worth of output is allowed. Excess output is handled according to
+// the policy selected with WithRatePolicy (RateThrottle by default).
+func WithOutputRateLimit(bytesPerSec int64) Option {
+	return func(app *App) {
+		app.rateLimit = bytesPerSec
+	}
+}
+
+// WithRatePolicy select policy used, when rate set
+// with WithOutputRateLimit is exceeded.
+func WithRatePolicy(policy RatePolicy) Option {
+	return func(app *App) {
+		app.ratePolicy = policy
+	}
+}
+
+func (app *App) rateFilter(w io.Writer, stream string) io.Writer {
+	if app.rateLimit <= 0 {
+		return w
+	}
+	return &rateWriter{w: w, rate: app.rateLimit, policy: app.ratePolicy,
+		tokens: float64(app.rateLimit), last: time.Now()}
+}
+
+// rateWriter pass output through token bucket.
+type rateWriter struct {
+	sync.Mutex
+	w          io.Writer
+	rate       int64
+	policy     RatePolicy
+	tokens     float64
+	last       time.Time
+	suppressed int64
+}
+
+// refill add tokens accumulated since the last call.
+func (r *rateWriter) refill() {
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * float64(r.rate)
+	if r.tokens > float64(r.rate) {
+		r.tokens = float64(r.rate)
+	}
+	r.last = now
+}
+
+func (r *rateWriter) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	n := len(p)
+	r.refill()
+	if r.policy == RateSummarize {
+		allowed := len(p)
+		if float64(allowed) > r.tokens {
+			allowed = int(r.tokens)
+		}
+		if allowed > 0 {
+			if err := r.summary(); err != nil {
+				return 0, err
+			}
+			r.tokens -= float64(allowed)
+			_, err := r.w.Write(p[:allowed])
+			if err != nil {
+				return 0, err
+			}
+		}
+		r.suppressed += int64(len(p) - allowed)
+		return n, nil
+	}
+	for len(p) > 0 {
+		if r.tokens < 1 {
+			time.Sleep(time.Duration((1 - r.tokens) / float64(r.rate) * float64(time.Second)))
+			r.refill()
+			continue
+		}
+		chunk := p
+		if float64(len(chunk)) > r.tokens {
+			chunk = chunk[:int(r.tokens)]
+		}
+		_, err := r.w.Write(chunk)
+		if err != nil {
+			return 0, err
+		}
+		r.tokens -= float64(len(chunk))
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// summary write marker of suppressed output, if any.
+func (r *rateWriter) summary() error {
+	if r.suppressed == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(r.w, "\n... [%d bytes suppressed by rate limit] ...\n", r.suppressed)
+	r.suppressed = 0
+	return err
+}
+
+// Flush write marker of output suppressed at the end.
+func (r *rateWriter) Flush() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.summary()
+}
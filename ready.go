@@ -0,0 +1,159 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultReadyTimeout limit WaitReady, unless WithReadyTimeout specified.
+const DefaultReadyTimeout = 30 * time.Second
+
+// readyPoll is an interval between attempts of polling checks.
+const readyPoll = 100 * time.Millisecond
+
+// ErrExitedBeforeReady is returned by WaitReady,
+// if application exits before checks pass.
+var ErrExitedBeforeReady = errors.New("application exited before ready")
+
+// ReadyCheck block until application is ready to use, or context is done.
+type ReadyCheck func(ctx context.Context, app *App) error
+
+// WithReadyTimeout change time limit of WaitReady.
+func WithReadyTimeout(timeout time.Duration) Option {
+	return func(app *App) {
+		app.readyTimeout = timeout
+	}
+}
+
+// WaitReady block, until all checks pass, so server started can
+// be actually used. Error is returned, if timeout (DefaultReadyTimeout
+// or set WithReadyTimeout) expires, either application exits first.
+// Application is not killed in any case.
+func (app *App) WaitReady(checks ...ReadyCheck) error {
+	if app.getProcess() == nil {
+		return ErrNotStarted
+	}
+	timeout := app.readyTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	errs := make(chan error, len(checks))
+	for _, check := range checks {
+		go func(check ReadyCheck) {
+			errs <- check(ctx, app)
+		}(check)
+	}
+	for range checks {
+		var err error
+		select {
+		case err = <-errs:
+		case <-app.done:
+			return ErrExitedBeforeReady
+		}
+		if err == context.DeadlineExceeded {
+			return fmt.Errorf("application is not ready in %v", timeout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollReady call ready until it return true or error, or context is done.
+func pollReady(ctx context.Context, ready func() (bool, error)) error {
+	ticker := time.NewTicker(readyPoll)
+	defer ticker.Stop()
+	for {
+		ok, err := ready()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ReadyTCP wait, until connection to address is accepted.
+func ReadyTCP(address string) ReadyCheck {
+	return func(ctx context.Context, app *App) error {
+		var d net.Dialer
+		return pollReady(ctx, func() (bool, error) {
+			conn, err := d.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return false, nil
+			}
+			conn.Close()
+			return true, nil
+		})
+	}
+}
+
+// ReadyFile wait, until file (for instance, socket or pid file) exists.
+func ReadyFile(path string) ReadyCheck {
+	return func(ctx context.Context, app *App) error {
+		return pollReady(ctx, func() (bool, error) {
+			_, err := os.Stat(path)
+			return err == nil, nil
+		})
+	}
+}
+
+// ReadyHTTP wait, until GET request to url return status 200.
+func ReadyHTTP(url string) ReadyCheck {
+	return func(ctx context.Context, app *App) error {
+		return pollReady(ctx, func() (bool, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return false, err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return false, nil
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusOK, nil
+		})
+	}
+}
+
+// ReadyLine wait, until line of stdout or stderr match regular
+// expression. Application must be configured WithBroadcast, which
+// backlog keep lines written before WaitReady is called.
+func ReadyLine(pattern string) ReadyCheck {
+	re, err := regexp.Compile(pattern)
+	return func(ctx context.Context, app *App) error {
+		if err != nil {
+			return fmt.Errorf("invalid ready pattern %q: %v", pattern, err)
+		}
+		found := make(chan struct{})
+		var once sync.Once
+		match := func() { once.Do(func() { close(found) }) }
+		detach, err := app.Attach(&matchWriter{re: re, match: match},
+			&matchWriter{re: re, match: match})
+		if err != nil {
+			return err
+		}
+		defer detach()
+		select {
+		case <-found:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
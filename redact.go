@@ -0,0 +1,84 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// RedactMask replace secrets found in output.
+const RedactMask = "***"
+
+// WithRedact mask secrets (tokens, passwords), matching regular
+// expressions specified, in everything the package capture: output
+// passed to writers, tees, tail buffer and error messages, preventing
+// credentials leakage from verbose tools. Use regexp.QuoteMeta to
+// mask literal values. Invalid expression make Start to fail.
+// Output is redacted line by line, so secret can't be split
+// between writes.
+func WithRedact(patterns ...string) Option {
+	return func(app *App) {
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				app.setError(fmt.Errorf("invalid redact pattern %q: %v", pattern, err))
+				return
+			}
+			app.redact = append(app.redact, re)
+		}
+	}
+}
+
+// Redact mask secrets configured with WithRedact in the string.
+func (app *App) Redact(s string) string {
+	for _, re := range app.redact {
+		s = re.ReplaceAllString(s, RedactMask)
+	}
+	return s
+}
+
+func (app *App) redactFilter(w io.Writer, stream string) io.Writer {
+	if len(app.redact) == 0 {
+		return w
+	}
+	return &redactWriter{app: app, w: w}
+}
+
+// redactWriter buffer output till the end of line (or carriage
+// return), and write it with secrets masked.
+type redactWriter struct {
+	app *App
+	w   io.Writer
+	buf []byte
+}
+
+// maxRedactLine limit buffered incomplete line.
+const maxRedactLine = 64 * 1024
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	i := bytes.LastIndexAny(r.buf, "\r\n")
+	if i == -1 && len(r.buf) < maxRedactLine {
+		return len(p), nil
+	}
+	if i == -1 {
+		i = len(r.buf) - 1
+	}
+	_, err := io.WriteString(r.w, r.app.Redact(string(r.buf[:i+1])))
+	r.buf = append(r.buf[:0], r.buf[i+1:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush write remaining incomplete line.
+func (r *redactWriter) Flush() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(r.w, r.app.Redact(string(r.buf)))
+	r.buf = nil
+	return err
+}
@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"io"
+	"os"
+)
+
+// fileTarget describe file output stream is redirected to.
+type fileTarget struct {
+	path   string
+	append bool
+	perm   os.FileMode
+}
+
+// WithStdoutFile redirect stdout to file, when Run/Start called with
+// nil stdout: file is created with perm, if not exists, and truncated,
+// unless appendMode is set. File descriptor is passed to application
+// directly, so output doesn't pass through the Go process, and is not
+// lost, if the calling process crashes (unless tees or output filters
+// are configured, which require copying). File is opened on every
+// start and closed once application exits.
+func WithStdoutFile(path string, appendMode bool, perm os.FileMode) Option {
+	return func(app *App) {
+		app.stdoutFile = &fileTarget{path: path, append: appendMode, perm: perm}
+	}
+}
+
+// WithStderrFile redirect stderr to file, see WithStdoutFile.
+// If the same path is used for both streams, file is opened once,
+// so streams share the same descriptor, as shell "2>&1" does.
+func WithStderrFile(path string, appendMode bool, perm os.FileMode) Option {
+	return func(app *App) {
+		app.stderrFile = &fileTarget{path: path, append: appendMode, perm: perm}
+	}
+}
+
+func (t *fileTarget) open() (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if t.append {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+	return os.OpenFile(t.path, flags, t.perm)
+}
+
+// fileStreams open files configured for nil streams.
+func (app *App) fileStreams(stdout, stderr io.Writer) (io.Writer, io.Writer, error) {
+	var outFile *os.File
+	if stdout == nil && app.stdoutFile != nil {
+		file, err := app.stdoutFile.open()
+		if err != nil {
+			return nil, nil, err
+		}
+		app.closers = append(app.closers, file)
+		stdout, outFile = file, file
+	}
+	if stderr == nil && app.stderrFile != nil {
+		if outFile != nil && app.stderrFile.path == app.stdoutFile.path {
+			return stdout, outFile, nil
+		}
+		file, err := app.stderrFile.open()
+		if err != nil {
+			return nil, nil, err
+		}
+		app.closers = append(app.closers, file)
+		stderr = file
+	}
+	return stdout, stderr, nil
+}
@@ -0,0 +1,207 @@
+package shell
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report describe completed run of application in the form,
+// which can be persisted and displayed by orchestration tools.
+type Report struct {
+	Command string
+	Args    []string
+	// Env is a difference of application environments
+	// from the environments of the calling process, see App.EnvDiff.
+	Env      EnvChanges
+	Dir      string
+	Pid      int
+	Started  time.Time
+	Finished time.Time
+	Duration time.Duration
+	ExitCode int
+	Signal   os.Signal
+	Error    error
+	// Truncated is true, if output was truncated, see WithMaxCaptureBytes.
+	Truncated bool
+	// OutputFile is a path of the file output was captured to,
+	// see WithGzipCapture.
+	OutputFile string
+	// Tail contain last lines of output, see WithTailBuffer.
+	Tail []string
+	// Lines contain output recorded WithTimestamps.
+	Lines []TimestampedLine
+	// IO is I/O accounting, see WithIOAccounting.
+	IO *IOStats
+}
+
+// EnvChanges list environments added, overridden (in the form
+// "key=value") and unset (keys), comparing to the calling process ones.
+type EnvChanges struct {
+	Added      []string `json:"added,omitempty"`
+	Overridden []string `json:"overridden,omitempty"`
+	Unset      []string `json:"unset,omitempty"`
+}
+
+// Report return report of application run, once it has finished,
+// with arguments, environments, tail and error redacted according
+// to WithRedact.
+func (app *App) Report() (*Report, error) {
+	if app.done == nil {
+		return nil, ErrNotStarted
+	}
+	select {
+	case <-app.done:
+	default:
+		return nil, ErrNotFinished
+	}
+	st := app.ExitCodeOrError()
+	r := &Report{Command: app.resolvedPath(), Dir: app.cmd.Dir, Pid: app.Pid(),
+		Started: app.startTime, Finished: app.endTime,
+		Duration: app.endTime.Sub(app.startTime), ExitCode: st.ExitCode,
+		Signal: app.ExitSignal(), Truncated: app.Truncated(),
+		OutputFile: app.gzipPath, Tail: app.TailLines(app.tailSize)}
+	for _, arg := range app.cmd.Args[1:] {
+		r.Args = append(r.Args, app.Redact(arg))
+	}
+	if st.Error != nil {
+		r.Error = errors.New(app.Redact(st.Error.Error()))
+	}
+	for i, line := range r.Tail {
+		r.Tail[i] = app.Redact(line)
+	}
+	r.IO = app.IOStats()
+	r.Lines = app.TimestampedLines()
+	for i := range r.Lines {
+		r.Lines[i].Text = app.Redact(r.Lines[i].Text)
+	}
+	r.Env = app.EnvDiff()
+	return r, nil
+}
+
+// EnvDiff compare environments passed to application with the calling
+// process ones, which is what to look at, when command works in shell,
+// but fails being started from Go. Once application is started,
+// effective environments are compared, including ones added by the
+// package (secrets, PATH of WithExtraPath and so on), otherwise
+// environments configured so far. Values are redacted according
+// to WithRedact, secrets of env providers are masked always (and so
+// reported as overridden, if the calling process has them as well).
+// Remote executors receive only added environments, so nothing
+// is reported as overridden or unset for them.
+func (app *App) EnvDiff() EnvChanges {
+	var changes EnvChanges
+	parent := envMap(os.Environ())
+	app.mutex.Lock()
+	runEnv := app.runEnv
+	app.mutex.Unlock()
+	env := parent
+	if runEnv != nil {
+		env = envMap(runEnv)
+	} else if app.cmd.Env != nil {
+		env = envMap(app.cmd.Env)
+	}
+	if app.executor != LocalExecutor {
+		// Only added environments are passed to the remote process.
+		if runEnv == nil {
+			env = envMap(app.env)
+		}
+		parent = nil
+	}
+	for key, value := range env {
+		if old, ok := parent[key]; !ok {
+			changes.Added = append(changes.Added, key+"="+app.Redact(value))
+		} else if old != value {
+			changes.Overridden = append(changes.Overridden, key+"="+app.Redact(value))
+		}
+	}
+	for key := range parent {
+		if _, ok := env[key]; !ok {
+			changes.Unset = append(changes.Unset, key)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Overridden)
+	sort.Strings(changes.Unset)
+	return changes
+}
+
+// snapshotEnv keep environments the process is started with,
+// masking values of secrets, so they are never kept in App.
+func (app *App) snapshotEnv(cmd *exec.Cmd, env, secrets []string) {
+	base := env
+	if app.executor == LocalExecutor {
+		base = cmd.Env
+		if base == nil {
+			base = os.Environ()
+		}
+	}
+	masked := make(map[string]bool, len(secrets))
+	for _, item := range secrets {
+		key, _, _ := strings.Cut(item, "=")
+		masked[key] = true
+	}
+	snapshot := make([]string, 0, len(base))
+	for _, item := range base {
+		key, _, _ := strings.Cut(item, "=")
+		if masked[key] {
+			item = key + "=" + RedactMask
+		}
+		snapshot = append(snapshot, item)
+	}
+	app.mutex.Lock()
+	app.runEnv = snapshot
+	app.mutex.Unlock()
+}
+
+// envMap convert environments to map, where the last entry win.
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, item := range env {
+		key, value, _ := strings.Cut(item, "=")
+		m[key] = value
+	}
+	return m
+}
+
+// MarshalJSON implement json.Marshaler interface: duration
+// is reported in milliseconds, signal and error as strings.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	type report struct {
+		Command    string            `json:"command"`
+		Args       []string          `json:"args"`
+		Env        EnvChanges        `json:"env"`
+		Dir        string            `json:"dir,omitempty"`
+		Pid        int               `json:"pid,omitempty"`
+		Started    time.Time         `json:"started"`
+		Finished   time.Time         `json:"finished"`
+		DurationMs float64           `json:"durationMs"`
+		ExitCode   int               `json:"exitCode"`
+		Signal     string            `json:"signal,omitempty"`
+		Error      string            `json:"error,omitempty"`
+		Truncated  bool              `json:"truncated,omitempty"`
+		OutputFile string            `json:"outputFile,omitempty"`
+		Tail       []string          `json:"tail,omitempty"`
+		Lines      []TimestampedLine `json:"lines,omitempty"`
+		IO         *IOStats          `json:"io,omitempty"`
+	}
+	v := report{Command: r.Command, Args: r.Args, Env: r.Env, Dir: r.Dir,
+		Pid: r.Pid, Started: r.Started, Finished: r.Finished,
+		DurationMs: float64(r.Duration) / float64(time.Millisecond),
+		ExitCode:   r.ExitCode, Truncated: r.Truncated,
+		OutputFile: r.OutputFile, Tail: r.Tail, Lines: r.Lines, IO: r.IO}
+	if v.Args == nil {
+		v.Args = []string{}
+	}
+	if r.Signal != nil {
+		v.Signal = r.Signal.String()
+	}
+	if r.Error != nil {
+		v.Error = r.Error.Error()
+	}
+	return json.Marshal(&v)
+}
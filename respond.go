@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// respondRule is a prompt pattern with the answer.
+type respondRule struct {
+	prompt   *regexp.Regexp
+	response string
+}
+
+// AutoRespond watch application stdout and stderr (or pseudo-terminal
+// with RunTerminal) and write response to application stdin, once
+// output match prompt pattern, for unattended automation of tools
+// insisting on asking questions like "Are you sure? [y/N]". Output
+// matched is consumed, so every prompt is answered once. If stdin
+// is passed to Run/Start, it's merged with responses (application
+// doesn't get EOF then till exit). Must be called
+// before Start; with StartPTY caller read terminal output itself,
+// so master side must be wrapped with RespondPTY.
+func (app *App) AutoRespond(rules map[*regexp.Regexp]string) {
+	for prompt, response := range rules {
+		app.respond = append(app.respond, respondRule{prompt, response})
+	}
+	// Keep order stable, since map order is random.
+	sort.SliceStable(app.respond, func(i, j int) bool {
+		return app.respond[i].prompt.String() < app.respond[j].prompt.String()
+	})
+}
+
+// respondWindow is a size of recent output, prompts are searched in.
+const respondWindow = 4096
+
+// responder match output against prompts
+// and send responses to target writer.
+type responder struct {
+	sync.Mutex
+	rules   []respondRule
+	window  []byte
+	answers chan string
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newResponder(rules []respondRule, target io.Writer) *responder {
+	r := &responder{rules: rules, answers: make(chan string, 16),
+		done: make(chan struct{})}
+	go func() {
+		// Write asynchronously, since application might not
+		// read stdin, while output routine must not block.
+		for {
+			select {
+			case answer := <-r.answers:
+				if _, err := io.WriteString(target, answer); err != nil {
+					return
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func (r *responder) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.window = append(r.window, p...)
+	for {
+		pos, end, answer := -1, 0, ""
+		// Answer the earliest prompt, if several are matched.
+		for _, rule := range r.rules {
+			loc := rule.prompt.FindIndex(r.window)
+			if loc != nil && (pos == -1 || loc[0] < pos) {
+				pos, end, answer = loc[0], loc[1], rule.response
+			}
+		}
+		if pos == -1 {
+			break
+		}
+		r.window = r.window[end:]
+		select {
+		case r.answers <- answer:
+		case <-r.done:
+		}
+	}
+	if len(r.window) > respondWindow {
+		r.window = append(r.window[:0], r.window[len(r.window)-respondWindow:]...)
+	}
+	return len(p), nil
+}
+
+// Close stop sending responses.
+func (r *responder) Close() error {
+	r.once.Do(func() { close(r.done) })
+	return nil
+}
+
+// respondWriters create responder, if configured, writing to pipe,
+// which become application stdin, and return it for both streams.
+// Pseudo-terminal must stay stdin of application started with
+// StartPTY, so responses are written to master side then
+// (see RunTerminal and RespondPTY).
+func (app *App) respondWriters() (io.Writer, io.Writer, error) {
+	app.respondStdin, app.respondPipe = nil, nil
+	if len(app.respond) == 0 || app.ptyRun {
+		return nil, nil, nil
+	}
+	pr, pw := io.Pipe()
+	r := newResponder(app.respond, pw)
+	app.respondStdin = pr
+	app.respondPipe = pw
+	app.closers = append(app.closers, r, pw)
+	return r, r, nil
+}
+
+// mergeResponses return reader of responses merged with stdin,
+// if responder is active.
+func (app *App) mergeResponses(stdin io.Reader) io.Reader {
+	if app.respondStdin == nil {
+		return stdin
+	}
+	if stdin != nil {
+		w := app.respondPipe
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := stdin.Read(buf)
+				if n > 0 {
+					// Pipe serialize writes, so responses are not interleaved.
+					if _, err := w.Write(buf[:n]); err != nil {
+						return
+					}
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	return app.respondStdin
+}
+
+// RespondPTY wrap master side of pseudo-terminal returned by StartPTY,
+// so output read through it is matched against AutoRespond prompts,
+// and responses are written to master. Responding stop once
+// application exits. Master is returned as is, if no prompts set.
+func (app *App) RespondPTY(master io.ReadWriter) io.ReadWriter {
+	if len(app.respond) == 0 {
+		return master
+	}
+	r := newResponder(app.respond, master)
+	go func() {
+		app.wait()
+		r.Close()
+	}()
+	return &respondConn{ReadWriter: master, r: r}
+}
+
+// respondConn pass data read to responder.
+type respondConn struct {
+	io.ReadWriter
+	r *responder
+}
+
+func (c *respondConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriter.Read(p)
+	if n > 0 {
+		c.r.Write(p[:n])
+	}
+	return n, err
+}
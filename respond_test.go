@@ -0,0 +1,36 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAutoRespond(t *testing.T) {
+	app := NewApp("sh", "-c", `printf 'Continue? [y/N] '; read answer; echo "got $answer"`)
+	app.AutoRespond(map[*regexp.Regexp]string{regexp.MustCompile(`Continue\? \[y/N\] `): "y\n"})
+	var stdout bytes.Buffer
+	st := app.Run(nil, &stdout, nil)
+	if st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if !strings.Contains(stdout.String(), "got y") {
+		t.Fatalf("response is not received, output %q", stdout.String())
+	}
+}
+
+func TestAutoRespondOnce(t *testing.T) {
+	app := NewApp("sh", "-c", `printf 'Name: '; read a; printf 'Name: '; read b; echo "$a $b"`)
+	app.AutoRespond(map[*regexp.Regexp]string{regexp.MustCompile(`Name: `): "x\n"})
+	var stdout bytes.Buffer
+	st := app.Run(nil, &stdout, nil)
+	if st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if !strings.HasSuffix(stdout.String(), "x x\n") {
+		t.Fatalf("every prompt is not answered, output %q", stdout.String())
+	}
+}
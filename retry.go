@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"time"
+)
+
+// ExitClass is a classification of application exit status.
+type ExitClass int
+
+const (
+	// ExitSuccess mean application succeeded.
+	ExitSuccess ExitClass = iota
+	// ExitTransient mean failure, which might disappear,
+	// if command is repeated (network failure, timeout, lock).
+	ExitTransient
+	// ExitPermanent mean failure, which repeat won't fix
+	// (invalid arguments, missing file and so on).
+	ExitPermanent
+)
+
+func (c ExitClass) String() string {
+	switch c {
+	case ExitSuccess:
+		return "success"
+	case ExitTransient:
+		return "transient"
+	}
+	return "permanent"
+}
+
+// Classifier classify exit status of application, used by
+// RunRetry and Breaker (see Breaker.SetClassifier).
+type Classifier func(st ExitCodeOrError) ExitClass
+
+// ClassifyCodes return classifier, where exit codes listed are
+// transient failures, and other non-zero codes are permanent ones.
+// Application terminated by signal (exit code -1) is a transient
+// failure, while failed to start is a permanent one.
+func ClassifyCodes(transient ...int) Classifier {
+	return func(st ExitCodeOrError) ExitClass {
+		if st.Error != nil {
+			return ExitPermanent
+		}
+		if st.ExitCode == 0 {
+			return ExitSuccess
+		}
+		if st.ExitCode == -1 {
+			return ExitTransient
+		}
+		for _, code := range transient {
+			if st.ExitCode == code {
+				return ExitTransient
+			}
+		}
+		return ExitPermanent
+	}
+}
+
+// Presets classifying documented exit codes of well-known tools.
+var (
+	// CurlClassifier treat as transient: failure to resolve proxy
+	// or host (5, 6), to connect (7), partial transfer (18), timeout
+	// (28), SSL handshake failure (35), empty reply (52) and network
+	// send/receive errors (55, 56). HTTP error reported with --fail (22)
+	// is permanent, since curl doesn't tell status code.
+	CurlClassifier = ClassifyCodes(5, 6, 7, 18, 28, 35, 52, 55, 56)
+	// RsyncClassifier treat as transient: protocol startup failure (5),
+	// socket I/O error (10), protocol data stream error (12), source
+	// files vanished (24), timeouts (30, 35) and SSH failure (255).
+	RsyncClassifier = ClassifyCodes(5, 10, 12, 24, 30, 35, 255)
+	// GitClassifier treat fatal error (128), which git report for
+	// network and lock failures, as transient. Note, that fatal errors,
+	// such as missing repository, are reported with the same code.
+	GitClassifier = ClassifyCodes(128)
+	// AptClassifier treat error (100), which apt-get report when
+	// package lists can't be downloaded or dpkg lock is held, as
+	// transient; apt doesn't distinguish other errors by exit code.
+	AptClassifier = ClassifyCodes(100)
+)
+
+// DefaultClassifier treat any failure as transient.
+func DefaultClassifier(st ExitCodeOrError) ExitClass {
+	if st.Error == nil && st.ExitCode == 0 {
+		return ExitSuccess
+	}
+	return ExitTransient
+}
+
+// RunRetry run copies of application (see App.Clone) synchronously,
+// until it succeeds, failure is permanent according to classify
+// (DefaultClassifier, if nil), or attempts are exhausted. Delay
+// between attempts doubles every time. Application of the last
+// attempt is returned along with its exit status.
+func RunRetry(app *App, attempts int, delay time.Duration, classify Classifier) (*App, ExitCodeOrError) {
+	if classify == nil {
+		classify = DefaultClassifier
+	}
+	var st ExitCodeOrError
+	var run *App
+	for i := 0; i < attempts || i == 0; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		run = app.Clone()
+		st = run.Run(nil, nil, nil)
+		if classify(st) != ExitTransient {
+			break
+		}
+	}
+	return run, st
+}
@@ -0,0 +1,177 @@
+package shell
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// RotatingWriter write to the file and rotate it, once size
+// exceed MaxSize: file is renamed to "path.1" (compressed with gzip
+// to "path.1.gz", if Compress set), previous ones are shifted,
+// and files beyond MaxFiles are removed. Writer can be passed
+// as stdout/stderr of long-running applications, giving them
+// log rotation without external logrotate setup.
+type RotatingWriter struct {
+	sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	compress bool
+	file     *os.File
+	size     int64
+}
+
+// NewRotatingWriter open (append to) the file, which is rotated after
+// maxSize bytes, keeping maxFiles rotated files.
+func NewRotatingWriter(path string, maxSize int64, maxFiles int, compress bool) (*RotatingWriter, error) {
+	w := &RotatingWriter{path: path, maxSize: maxSize,
+		maxFiles: maxFiles, compress: compress}
+	err := w.open()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = stat.Size()
+	return nil
+}
+
+// Write implement io.Writer interface. Data exceeding
+// the room left in current file is split, preferably
+// at line boundary, between current and next files.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	var total int
+	for len(p) > 0 {
+		chunk := p
+		if w.maxSize > 0 {
+			if w.size >= w.maxSize {
+				err := w.rotate()
+				if err != nil {
+					return total, err
+				}
+			}
+			if room := w.maxSize - w.size; int64(len(chunk)) > room {
+				chunk = chunk[:room]
+				if i := bytes.LastIndexByte(chunk, '\n'); i != -1 {
+					chunk = chunk[:i+1]
+				}
+			}
+		}
+		n, err := w.file.Write(chunk)
+		w.size += int64(n)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+		if len(p) > 0 && w.maxSize > 0 {
+			// Current file is full, even if maxSize is not reached yet.
+			w.size = w.maxSize
+		}
+	}
+	return total, nil
+}
+
+// Rotate force rotation of the file.
+func (w *RotatingWriter) Rotate() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+func (w *RotatingWriter) name(i int) string {
+	if w.compress {
+		return fmt.Sprintf("%s.%d.gz", w.path, i)
+	}
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+func (w *RotatingWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	if w.maxFiles > 0 {
+		os.Remove(w.name(w.maxFiles))
+		for i := w.maxFiles - 1; i >= 1; i-- {
+			os.Rename(w.name(i), w.name(i+1))
+		}
+		if w.compress {
+			err = gzipFile(w.path, w.name(1))
+		} else {
+			err = os.Rename(w.path, w.name(1))
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		err = os.Truncate(w.path, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return w.open()
+}
+
+// Close close current file.
+func (w *RotatingWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// gzipFile compress src to dst and remove src.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err2 := out.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,99 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrScheduleCancelled is reported by Scheduled.Wait,
+// if start has been cancelled before launch.
+var ErrScheduleCancelled = errors.New("scheduled start is cancelled")
+
+// Scheduled is a handle of application start deferred
+// with StartAt or StartAfter.
+type Scheduled struct {
+	app       *App
+	mutex     sync.Mutex
+	timer     *time.Timer
+	launched  chan struct{}
+	cancelled bool
+	ch        chan ExitCodeOrError
+	err       error
+}
+
+// StartAt start application asynchronously at time t (immediately,
+// if time has passed already), as Start does with the same arguments.
+// Launch can be cancelled with Scheduled.Cancel.
+func (app *App) StartAt(t time.Time, stdin io.Reader, stdout, stderr io.Writer) *Scheduled {
+	return app.StartAfter(time.Until(t), stdin, stdout, stderr)
+}
+
+// StartAfter start application asynchronously once delay
+// passed, as Start does with the same arguments.
+// Launch can be cancelled with Scheduled.Cancel.
+func (app *App) StartAfter(delay time.Duration, stdin io.Reader, stdout, stderr io.Writer) *Scheduled {
+	s := &Scheduled{app: app, launched: make(chan struct{})}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.timer = time.AfterFunc(delay, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.cancelled {
+			return
+		}
+		s.ch, s.err = app.Start(stdin, stdout, stderr)
+		close(s.launched)
+	})
+	return s
+}
+
+// App return application scheduled.
+func (s *Scheduled) App() *App {
+	return s.app
+}
+
+// Cancel prevent launch, if it has not happened yet,
+// and report whether launch was cancelled.
+func (s *Scheduled) Cancel() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-s.launched:
+		return s.cancelled
+	default:
+	}
+	s.timer.Stop()
+	s.cancelled = true
+	close(s.launched)
+	return true
+}
+
+// Launched return channel, which is closed once application
+// has been started (or failed to start), or launch is cancelled.
+func (s *Scheduled) Launched() <-chan struct{} {
+	return s.launched
+}
+
+// Start block until launch and return result of Start call,
+// ErrScheduleCancelled if launch has been cancelled.
+func (s *Scheduled) Start() (chan ExitCodeOrError, error) {
+	<-s.launched
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancelled {
+		return nil, ErrScheduleCancelled
+	}
+	return s.ch, s.err
+}
+
+// Wait block until application launched and finished,
+// and return its exit status, either error of start.
+func (s *Scheduled) Wait() ExitCodeOrError {
+	_, err := s.Start()
+	if err != nil {
+		return ExitCodeOrError{Error: err}
+	}
+	return s.app.wait()
+}
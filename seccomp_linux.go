@@ -0,0 +1,180 @@
+//go:build linux && (amd64 || arm64)
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"syscall"
+	"unsafe"
+)
+
+// Seccomp profile names, accepted by WithSeccompProfile.
+const (
+	// SeccompDefault deny syscalls used to administer the system
+	// (mount, reboot, module loading, ptrace, namespaces and so on).
+	SeccompDefault = "default"
+	// SeccompNoNetwork deny the same syscalls as SeccompDefault,
+	// plus socket creation, so application can't use network.
+	SeccompNoNetwork = "nonet"
+)
+
+var seccompDenied = []string{
+	"acct", "add_key", "bpf", "clock_adjtime", "clock_settime",
+	"delete_module", "finit_module", "init_module", "kexec_file_load",
+	"kexec_load", "keyctl", "mount", "move_mount", "open_by_handle_at",
+	"open_tree", "perf_event_open", "pivot_root", "process_vm_readv",
+	"process_vm_writev", "ptrace", "quotactl", "reboot", "request_key",
+	"setdomainname", "sethostname", "setns", "settimeofday", "swapoff",
+	"swapon", "umount2", "unshare", "userfaultfd",
+}
+
+var seccompProfiles = map[string][]string{
+	SeccompDefault:   seccompDenied,
+	SeccompNoNetwork: append(append([]string{}, seccompDenied...), "socket", "socketpair"),
+}
+
+// seccompEssential are the syscalls, which allow-list always contain,
+// since they are necessary for Go runtime thread application is forked
+// from, for the child till exec and for dynamic loader after exec.
+var seccompEssential = []string{
+	"brk", "chdir", "clone", "clone3", "close", "close_range", "dup3",
+	"execve", "exit", "exit_group", "fchdir", "fcntl", "futex", "getpid",
+	"getppid", "getrandom", "gettid", "ioctl", "madvise", "mmap", "mprotect",
+	"munmap", "nanosleep", "pidfd_open", "prctl", "prlimit64", "read",
+	"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sched_yield",
+	"setpgid", "setsid", "sigaltstack", "tgkill", "wait4", "waitid", "write",
+	"openat", "newfstatat", "fstat", "pread64", "set_tid_address",
+	"set_robust_list", "rseq", "getuid", "geteuid", "getgid", "getegid",
+	"arch_prctl", "access", "pipe2", "faccessat", "faccessat2", "lseek", "statx",
+}
+
+// WithSeccompProfile apply seccomp-bpf filter with named profile
+// (SeccompDefault or SeccompNoNetwork) to application: syscalls denied
+// fail with EPERM. Filter is installed (with no_new_privs flag) on the
+// thread application is forked from, so it's inherited by all its
+// children and can't be removed. Only LocalExecutor is supported.
+func WithSeccompProfile(name string) Option {
+	return func(app *App) {
+		denied, ok := seccompProfiles[name]
+		if !ok {
+			app.setError(fmt.Errorf("unknown seccomp profile %q", name))
+			return
+		}
+		app.addSeccomp(denied, false)
+	}
+}
+
+// WithSeccompAllow apply seccomp-bpf filter, which allow only syscalls
+// listed (plus small set necessary to start process and run dynamic
+// loader), others fail with EPERM. Syscalls are specified by names
+// used in Linux sources, like "openat" or "read", names unknown on
+// the current architecture are ignored.
+func WithSeccompAllow(syscalls ...string) Option {
+	return func(app *App) {
+		app.addSeccomp(append(syscalls, seccompEssential...), true)
+	}
+}
+
+func (app *App) addSeccomp(names []string, allow bool) {
+	prog := seccompFilter(names, allow)
+	app.threadSetup = append(app.threadSetup, func() error {
+		return installSeccomp(prog)
+	})
+}
+
+const (
+	bpfLd  = 0x00
+	bpfW   = 0x00
+	bpfAbs = 0x20
+	bpfJmp = 0x05
+	bpfJeq = 0x10
+	bpfJge = 0x30
+	bpfK   = 0x00
+	bpfRet = 0x06
+
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	seccompSetModeFilter = 2
+	prSetSeccomp         = 22
+
+	// Offsets in struct seccomp_data.
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+type sockFprog struct {
+	len    uint16
+	filter *sockFilter
+}
+
+func bpfStmt(code uint16, k uint32) sockFilter {
+	return sockFilter{code: code, k: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) sockFilter {
+	return sockFilter{code: code, jt: jt, jf: jf, k: k}
+}
+
+// seccompFilter compile BPF program, which return allow action
+// for syscalls listed, if allow is true, and EPERM otherwise,
+// or vice versa. Syscalls unknown on this architecture are skipped.
+func seccompFilter(names []string, allow bool) []sockFilter {
+	match, other := uint32(seccompRetAllow), uint32(seccompRetErrno|uint32(syscall.EPERM))
+	if !allow {
+		match, other = other, match
+	}
+	nrs := map[uint32]bool{}
+	for _, name := range names {
+		if nr, ok := seccompSyscalls[name]; ok {
+			nrs[nr] = true
+		}
+	}
+	sorted := make([]uint32, 0, len(nrs))
+	for nr := range nrs {
+		sorted = append(sorted, nr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	prog := []sockFilter{
+		bpfStmt(bpfLd|bpfW|bpfAbs, seccompDataArch),
+		bpfJump(bpfJmp|bpfJeq|bpfK, seccompAuditArch, 1, 0),
+		bpfStmt(bpfRet|bpfK, seccompRetKillProcess),
+		bpfStmt(bpfLd|bpfW|bpfAbs, seccompDataNr),
+	}
+	if seccompX32Bit != 0 {
+		// Deny x32 ABI syscalls, which would bypass the filter.
+		prog = append(prog, bpfJump(bpfJmp|bpfJge|bpfK, seccompX32Bit, 0, 1),
+			bpfStmt(bpfRet|bpfK, seccompRetErrno|uint32(syscall.EPERM)))
+	}
+	for _, nr := range sorted {
+		prog = append(prog, bpfJump(bpfJmp|bpfJeq|bpfK, nr, 0, 1),
+			bpfStmt(bpfRet|bpfK, match))
+	}
+	prog = append(prog, bpfStmt(bpfRet|bpfK, other))
+	return prog
+}
+
+// installSeccomp install filter on the current thread.
+func installSeccomp(prog []sockFilter) error {
+	err := prctl(prSetNoNewPrivs, 1)
+	if err != nil {
+		return os.NewSyscallError("prctl", err)
+	}
+	fprog := sockFprog{len: uint16(len(prog)), filter: &prog[0]}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetSeccomp,
+		seccompSetModeFilter, uintptr(unsafe.Pointer(&fprog)), 0, 0, 0)
+	if errno != 0 {
+		return os.NewSyscallError("prctl", errno)
+	}
+	return nil
+}
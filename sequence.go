@@ -0,0 +1,75 @@
+package shell
+
+// ErrorMode define how RunSequence handle failed step: the one,
+// which failed to start, or exited with error or non-zero exit code.
+type ErrorMode struct {
+	// Continue run remaining steps after failed one, collecting errors,
+	// instead of stopping on the first failure.
+	Continue bool
+	// IgnoreCodes list non-zero exit codes, which mean success.
+	IgnoreCodes []int
+}
+
+var (
+	// StopOnError stop sequence on the first failed step.
+	StopOnError = ErrorMode{}
+	// ContinueOnError run all steps, collecting errors.
+	ContinueOnError = ErrorMode{Continue: true}
+)
+
+// IgnoreExitCodes return mode stopping on the first failed step,
+// where exit codes listed are not considered failure.
+func IgnoreExitCodes(codes ...int) ErrorMode {
+	return ErrorMode{IgnoreCodes: codes}
+}
+
+// failed report, whether step exit status is a failure.
+func (m ErrorMode) failed(st ExitCodeOrError) bool {
+	if st.Error != nil {
+		return true
+	}
+	if st.ExitCode == 0 {
+		return false
+	}
+	for _, code := range m.IgnoreCodes {
+		if st.ExitCode == code {
+			return false
+		}
+	}
+	return true
+}
+
+// StepResult is an outcome of the sequence step.
+type StepResult struct {
+	App    *App
+	Status ExitCodeOrError
+	// Failed is true, if step failed according to ErrorMode.
+	Failed bool
+	// Skipped is true, if step has not been run,
+	// since sequence stopped on the previous failure.
+	Skipped bool
+}
+
+// RunSequence run applications one by one, as shell script does,
+// with stdio configured on every App (see SetStdin*, TeeStdout,
+// WithDefaultStream), handling failures according to mode.
+// Result of every step is returned, along with *MultiError describing
+// failed steps, if any.
+func RunSequence(apps []*App, mode ErrorMode) ([]StepResult, error) {
+	results := make([]StepResult, len(apps))
+	errs := &MultiError{Total: len(apps)}
+	for i, app := range apps {
+		results[i].App = app
+		if len(errs.Errors) > 0 && !mode.Continue {
+			results[i].Skipped = true
+			continue
+		}
+		st := app.Run(nil, nil, nil)
+		results[i].Status = st
+		if mode.failed(st) {
+			results[i].Failed = true
+			errs.add("step", i, app, st)
+		}
+	}
+	return results, errs.errOrNil()
+}
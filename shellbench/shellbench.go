@@ -0,0 +1,267 @@
+// Package shellbench measure overhead of github.com/d2r2/go-shell
+// package itself: spawn latency compared to plain os/exec, throughput
+// of output capture and of pipelines, across configurations (sets of
+// options), so users can validate performance regressions, when
+// tuning buffers, filters and modes:
+//
+//	results, err := shellbench.Run(shellbench.Bench{Configs: []shellbench.Config{
+//		{Name: "default"},
+//		{Name: "timestamps", Options: []shell.Option{shell.WithTimestamps("")}},
+//	}})
+//	shellbench.WriteText(os.Stdout, results)
+//
+// Generator of output is "head -c N /dev/zero" by default,
+// so POSIX system is required, unless Generator is set.
+package shellbench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	shell "github.com/d2r2/go-shell"
+)
+
+// Defaults used, if fields of Bench are zero.
+const (
+	DefaultRuns   = 100
+	DefaultBytes  = 64 << 20
+	DefaultStages = 3
+)
+
+// Config is a named set of options applied to every
+// application run by benchmark.
+type Config struct {
+	Name    string
+	Options []shell.Option
+}
+
+// Bench describe benchmark to run with Run.
+type Bench struct {
+	// Runs is a number of spawns measured, DefaultRuns if zero.
+	Runs int
+	// Bytes is a size of output generated for throughput
+	// measurement, DefaultBytes if zero.
+	Bytes int64
+	// Stages is a number of "cat" stages following generator
+	// in pipeline, DefaultStages if zero.
+	Stages int
+	// Configs list configurations to compare,
+	// single one without options, if empty.
+	Configs []Config
+	// Spawn is a command line, which spawn latency is measured,
+	// "true" if empty.
+	Spawn []string
+	// Generator create application, which write n bytes to stdout.
+	Generator func(n int64) *shell.App
+	// Filter create pipeline stage copying stdin to stdout.
+	Filter func() *shell.App
+}
+
+// Latency is a distribution of spawn latency: time from
+// start till exit status is received.
+type Latency struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Result is an outcome of benchmark of single configuration.
+type Result struct {
+	Config string
+	// Spawn is a latency of applications run with configuration.
+	Spawn Latency
+	// Baseline is a latency of the same command run with os/exec.
+	Baseline Latency
+	// Overhead is a difference of mean latencies.
+	Overhead time.Duration
+	// Capture is a throughput of stdout capture in bytes per second.
+	Capture float64
+	// Pipeline is a throughput of pipeline in bytes per second.
+	Pipeline float64
+}
+
+func (b *Bench) defaults() {
+	if b.Runs <= 0 {
+		b.Runs = DefaultRuns
+	}
+	if b.Bytes <= 0 {
+		b.Bytes = DefaultBytes
+	}
+	if b.Stages <= 0 {
+		b.Stages = DefaultStages
+	}
+	if len(b.Configs) == 0 {
+		b.Configs = []Config{{Name: "default"}}
+	}
+	if len(b.Spawn) == 0 {
+		b.Spawn = []string{"true"}
+	}
+	if b.Generator == nil {
+		b.Generator = func(n int64) *shell.App {
+			return shell.NewApp("head", "-c", fmt.Sprint(n), "/dev/zero")
+		}
+	}
+	if b.Filter == nil {
+		b.Filter = func() *shell.App { return shell.NewApp("cat") }
+	}
+}
+
+// Run measure every configuration of benchmark one by one.
+func Run(b Bench) ([]Result, error) {
+	b.defaults()
+	results := make([]Result, 0, len(b.Configs))
+	for _, config := range b.Configs {
+		r, err := b.run(config)
+		if err != nil {
+			return results, fmt.Errorf("config %q: %v", config.Name, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func (b *Bench) run(config Config) (Result, error) {
+	r := Result{Config: config.Name}
+	var err error
+	r.Baseline, err = SpawnBaseline(b.Runs, b.Spawn[0], b.Spawn[1:]...)
+	if err != nil {
+		return r, err
+	}
+	r.Spawn, err = SpawnLatency(func() *shell.App {
+		return shell.NewApp(b.Spawn[0], b.Spawn[1:]...).With(config.Options...)
+	}, b.Runs)
+	if err != nil {
+		return r, err
+	}
+	r.Overhead = r.Spawn.Mean - r.Baseline.Mean
+	r.Capture, err = CaptureThroughput(b.Generator(b.Bytes).With(config.Options...), b.Bytes)
+	if err != nil {
+		return r, err
+	}
+	apps := []*shell.App{b.Generator(b.Bytes).With(config.Options...)}
+	for i := 0; i < b.Stages; i++ {
+		apps = append(apps, b.Filter().With(config.Options...))
+	}
+	r.Pipeline, err = PipelineThroughput(shell.NewPipeline(apps...), b.Bytes)
+	return r, err
+}
+
+// SpawnLatency run applications created by newApp runs
+// times one by one, and return distribution of latency.
+func SpawnLatency(newApp func() *shell.App, runs int) (Latency, error) {
+	samples := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		app := newApp()
+		start := time.Now()
+		st := app.Run(nil, nil, nil)
+		if err := status(st); err != nil {
+			return Latency{}, err
+		}
+		samples = append(samples, time.Since(start))
+	}
+	return latency(samples), nil
+}
+
+// SpawnBaseline run command with plain os/exec runs
+// times, to compare with SpawnLatency.
+func SpawnBaseline(runs int, name string, args ...string) (Latency, error) {
+	samples := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if err := exec.Command(name, args...).Run(); err != nil {
+			return Latency{}, err
+		}
+		samples = append(samples, time.Since(start))
+	}
+	return latency(samples), nil
+}
+
+// CaptureThroughput run application, which is expected to write n
+// bytes to stdout, and return capture rate in bytes per second.
+func CaptureThroughput(app *shell.App, n int64) (float64, error) {
+	var w countWriter
+	start := time.Now()
+	if err := status(app.Run(nil, &w, nil)); err != nil {
+		return 0, err
+	}
+	return rate(w.n.Load(), n, time.Since(start))
+}
+
+// PipelineThroughput run pipeline, which is expected to write n
+// bytes to stdout, and return its rate in bytes per second.
+func PipelineThroughput(p *shell.Pipeline, n int64) (float64, error) {
+	var w countWriter
+	start := time.Now()
+	if err := status(p.Run(nil, &w, nil)); err != nil {
+		return 0, err
+	}
+	return rate(w.n.Load(), n, time.Since(start))
+}
+
+// WriteText render results as a table.
+func WriteText(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join([]string{"CONFIG", "SPAWN P50", "SPAWN P99",
+		"BASELINE P50", "OVERHEAD", "CAPTURE MB/s", "PIPELINE MB/s"}, "\t"))
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%.1f\t%.1f\n", r.Config,
+			r.Spawn.P50, r.Spawn.P99, r.Baseline.P50, r.Overhead,
+			r.Capture/(1<<20), r.Pipeline/(1<<20))
+	}
+	return tw.Flush()
+}
+
+type countWriter struct {
+	n atomic.Int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func status(st shell.ExitCodeOrError) error {
+	if st.Error != nil {
+		return st.Error
+	}
+	if st.ExitCode != 0 {
+		return fmt.Errorf("exit code %d", st.ExitCode)
+	}
+	return nil
+}
+
+func rate(got, want int64, d time.Duration) (float64, error) {
+	if got != want {
+		return 0, fmt.Errorf("received %d bytes, expected %d", got, want)
+	}
+	if d <= 0 {
+		return 0, errors.New("duration is too short to measure")
+	}
+	return float64(got) / d.Seconds(), nil
+}
+
+func latency(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	percentile := func(p float64) time.Duration {
+		return samples[int(p*float64(len(samples)-1)+0.5)]
+	}
+	return Latency{Mean: total / time.Duration(len(samples)),
+		P50: percentile(0.5), P90: percentile(0.9), P99: percentile(0.99),
+		Max: samples[len(samples)-1]}
+}
@@ -0,0 +1,26 @@
+module github.com/d2r2/go-shell/shellotel
+
+go 1.26.0
+
+require (
+	github.com/d2r2/go-shell v0.0.0-20261014153645-508a306acefe
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	golang.org/x/crypto v0.57.0 // indirect
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/term v0.46.0 // indirect
+	golang.org/x/text v0.42.0 // indirect
+)
+
+// Root module is used from the local tree, while the module is
+// developed; importers resolve the version required above.
+replace github.com/d2r2/go-shell => ../
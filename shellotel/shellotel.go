@@ -0,0 +1,202 @@
+// Package shellotel provide OpenTelemetry tracing for commands
+// executed with github.com/d2r2/go-shell package, so external
+// commands appear in distributed traces as separate spans.
+package shellotel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	shell "github.com/d2r2/go-shell"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultTailSize is a default size of stderr tail
+// recorded to the span on failure.
+const DefaultTailSize = 4096
+
+// Tracer open span for every application started with its Run or
+// Start methods. Span get attributes: command, args hash, pid,
+// exit code, signal and duration. On failure last TailSize bytes
+// of stderr are recorded as "shell.stderr_tail" span event.
+type Tracer struct {
+	// TailSize is a size of recorded stderr tail.
+	TailSize int
+
+	tracer trace.Tracer
+	mu     sync.Mutex
+	spans  map[*shell.App]*appSpan
+}
+
+type appSpan struct {
+	span trace.Span
+	tail *tailWriter
+}
+
+// NewTracer create tracer, which use provider specified,
+// either global one, if provider is nil.
+// Tracer register itself as shell.Observer to track
+// completion of applications it started.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	t := &Tracer{
+		TailSize: DefaultTailSize,
+		tracer:   provider.Tracer("github.com/d2r2/go-shell"),
+		spans:    make(map[*shell.App]*appSpan),
+	}
+	shell.AddObserver(t)
+	return t
+}
+
+// Close unregister tracer from shell observers.
+func (t *Tracer) Close() {
+	shell.RemoveObserver(t)
+}
+
+// Run is a traced version of App.Run, where span is a child of ctx.
+func (t *Tracer) Run(ctx context.Context, app *shell.App,
+	stdin io.Reader, stdout, stderr io.Writer) shell.ExitCodeOrError {
+
+	ch, err := t.Start(ctx, app, stdin, stdout, stderr)
+	if err != nil {
+		return shell.ExitCodeOrError{Error: err}
+	}
+	return <-ch
+}
+
+// Start is a traced version of App.Start, where span is a child of ctx.
+// Span is finished once application exits.
+func (t *Tracer) Start(ctx context.Context, app *shell.App,
+	stdin io.Reader, stdout, stderr io.Writer) (chan shell.ExitCodeOrError, error) {
+
+	_, span := t.tracer.Start(ctx, "exec "+app.Name(),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("shell.command", app.Name()),
+			attribute.String("shell.args_hash", argsHash(app.Args())),
+		))
+	tail := &tailWriter{size: t.TailSize}
+	if stderr != nil {
+		stderr = io.MultiWriter(stderr, tail)
+	} else {
+		stderr = tail
+	}
+	t.mu.Lock()
+	t.spans[app] = &appSpan{span: span, tail: tail}
+	t.mu.Unlock()
+	start := time.Now()
+	ch, err := app.Start(stdin, stdout, stderr)
+	if err != nil {
+		// Span is ended here, unless Failed is already notified.
+		t.Failed(app, err)
+		return nil, err
+	}
+	// Result replayed from cache or shared execution
+	// joined has no process, so Exited is never notified.
+	if app.Pid() == 0 {
+		go func() {
+			<-app.Done()
+			t.Exited(app, *app.ExitCodeOrError(), time.Since(start))
+		}()
+	}
+	return ch, nil
+}
+
+func (t *Tracer) get(app *shell.App, remove bool) *appSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.spans[app]
+	if remove {
+		delete(t.spans, app)
+	}
+	return s
+}
+
+// Started implement shell.Observer interface.
+func (t *Tracer) Started(app *shell.App) {
+	if s := t.get(app, false); s != nil && app.Pid() != 0 {
+		s.span.SetAttributes(attribute.Int("shell.pid", app.Pid()))
+	}
+}
+
+// Failed implement shell.Observer interface.
+func (t *Tracer) Failed(app *shell.App, err error) {
+	if s := t.get(app, true); s != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+		s.span.End()
+	}
+}
+
+// Exited implement shell.Observer interface.
+func (t *Tracer) Exited(app *shell.App, st shell.ExitCodeOrError, duration time.Duration) {
+	s := t.get(app, true)
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(
+		attribute.Int("shell.exit_code", st.ExitCode),
+		attribute.Float64("shell.duration_seconds", duration.Seconds()),
+	)
+	if sig := app.ExitSignal(); sig != nil {
+		s.span.SetAttributes(attribute.String("shell.signal", sig.String()))
+	}
+	if st.Error != nil || st.ExitCode != 0 {
+		s.span.AddEvent("shell.stderr_tail", trace.WithAttributes(
+			attribute.String("shell.stderr", s.tail.String())))
+		if st.Error != nil {
+			s.span.RecordError(st.Error)
+			s.span.SetStatus(codes.Error, st.Error.Error())
+		} else {
+			s.span.SetStatus(codes.Error, "non-zero exit code")
+		}
+	}
+	s.span.End()
+}
+
+// Killed implement shell.Observer interface.
+func (t *Tracer) Killed(app *shell.App) {
+	if s := t.get(app, false); s != nil {
+		s.span.AddEvent("shell.killed")
+	}
+}
+
+// argsHash return short hash of arguments, which identify
+// invocation without exposing arguments (might contain secrets).
+func argsHash(args []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// tailWriter keep last size bytes written.
+type tailWriter struct {
+	sync.Mutex
+	size int
+	buf  []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.size {
+		w.buf = append(w.buf[:0], w.buf[len(w.buf)-w.size:]...)
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	w.Lock()
+	defer w.Unlock()
+	return string(w.buf)
+}
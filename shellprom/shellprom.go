@@ -0,0 +1,114 @@
+// Package shellprom provide Prometheus metrics collector for
+// commands executed with github.com/d2r2/go-shell package.
+//
+// Collector should be registered both in Prometheus registry
+// and as an observer of shell package:
+//
+//	c := shellprom.NewCollector("myapp")
+//	prometheus.MustRegister(c)
+//	shell.AddObserver(c)
+package shellprom
+
+import (
+	"time"
+
+	shell "github.com/d2r2/go-shell"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exit classes used as "exit_class" label value.
+const (
+	ExitSuccess = "success"
+	ExitFailure = "failure"
+	ExitSignal  = "signal"
+	ExitError   = "error"
+)
+
+// Collector track executed commands, implementing both
+// prometheus.Collector and shell.Observer interfaces.
+type Collector struct {
+	runs     *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	running  prometheus.Gauge
+	kills    *prometheus.CounterVec
+}
+
+// Static check that Collector implement both interfaces.
+var _ prometheus.Collector = (*Collector)(nil)
+var _ shell.Observer = (*Collector)(nil)
+
+// NewCollector create collector with metrics named
+// under namespace (might be empty) and "shell" subsystem.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "shell", Name: "runs_total",
+			Help: "Total number of executed commands by command and exit class.",
+		}, []string{"command", "exit_class"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "shell", Name: "run_duration_seconds",
+			Help:    "Duration of executed commands.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 4, 10),
+		}, []string{"command"}),
+		running: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "shell", Name: "running_processes",
+			Help: "Number of currently running processes.",
+		}),
+		kills: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "shell", Name: "kills_total",
+			Help: "Total number of killed commands.",
+		}, []string{"command"}),
+	}
+}
+
+// Describe implement prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.runs.Describe(ch)
+	c.duration.Describe(ch)
+	c.running.Describe(ch)
+	c.kills.Describe(ch)
+}
+
+// Collect implement prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.runs.Collect(ch)
+	c.duration.Collect(ch)
+	c.running.Collect(ch)
+	c.kills.Collect(ch)
+}
+
+// Started implement shell.Observer interface.
+func (c *Collector) Started(app *shell.App) {
+	c.running.Inc()
+}
+
+// Failed implement shell.Observer interface.
+func (c *Collector) Failed(app *shell.App, err error) {
+	c.runs.WithLabelValues(app.Name(), ExitError).Inc()
+}
+
+// Exited implement shell.Observer interface.
+func (c *Collector) Exited(app *shell.App, st shell.ExitCodeOrError, duration time.Duration) {
+	c.running.Dec()
+	c.runs.WithLabelValues(app.Name(), ExitClass(st)).Inc()
+	c.duration.WithLabelValues(app.Name()).Observe(duration.Seconds())
+}
+
+// Killed implement shell.Observer interface.
+func (c *Collector) Killed(app *shell.App) {
+	c.kills.WithLabelValues(app.Name()).Inc()
+}
+
+// ExitClass classify exit status.
+func ExitClass(st shell.ExitCodeOrError) string {
+	switch {
+	case st.Error != nil:
+		return ExitError
+	case st.ExitCode == 0:
+		return ExitSuccess
+	case st.ExitCode < 0:
+		return ExitSignal
+	default:
+		return ExitFailure
+	}
+}
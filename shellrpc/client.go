@@ -0,0 +1,92 @@
+package shellrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	shell "github.com/d2r2/go-shell"
+	"google.golang.org/grpc"
+)
+
+// Client call remote service to execute commands there.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient create client, which use connection established by the caller.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+func (s *ExitStatus) exitCodeOrError() shell.ExitCodeOrError {
+	st := shell.ExitCodeOrError{ExitCode: s.ExitCode}
+	if s.Error != "" {
+		st.Error = errors.New(s.Error)
+	}
+	return st
+}
+
+// Run execute command remotely and return its output, once it exits.
+// Cancelling context kill remote command.
+func (c *Client) Run(ctx context.Context, req *RunRequest) ([]byte, []byte, shell.ExitCodeOrError) {
+	resp := &RunResponse{}
+	err := c.cc.Invoke(ctx, "/"+serviceName+"/Run", req, resp,
+		grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return nil, nil, shell.ExitCodeOrError{Error: err}
+	}
+	if resp.Status == nil {
+		return resp.Stdout, resp.Stderr, shell.ExitCodeOrError{Error: errNoStatus}
+	}
+	return resp.Stdout, resp.Stderr, resp.Status.exitCodeOrError()
+}
+
+// Stream execute command remotely, copy its output to stdout
+// and stderr as it arrives, and return exit status.
+// If onStart is not nil, it receive command ID, which can be used
+// to kill command with Kill call. Cancelling context kill remote command.
+func (c *Client) Stream(ctx context.Context, req *RunRequest, stdout, stderr io.Writer,
+	onStart func(id string)) shell.ExitCodeOrError {
+
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0],
+		"/"+serviceName+"/Stream", grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return shell.ExitCodeOrError{Error: err}
+	}
+	err = stream.SendMsg(req)
+	if err != nil {
+		return shell.ExitCodeOrError{Error: err}
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		return shell.ExitCodeOrError{Error: err}
+	}
+	for {
+		msg := &StreamResponse{}
+		err := stream.RecvMsg(msg)
+		if err == io.EOF {
+			return shell.ExitCodeOrError{Error: errNoStatus}
+		} else if err != nil {
+			return shell.ExitCodeOrError{Error: err}
+		}
+		if msg.ID != "" && onStart != nil {
+			onStart(msg.ID)
+		}
+		if len(msg.Stdout) > 0 && stdout != nil {
+			stdout.Write(msg.Stdout)
+		}
+		if len(msg.Stderr) > 0 && stderr != nil {
+			stderr.Write(msg.Stderr)
+		}
+		if msg.Status != nil {
+			return msg.Status.exitCodeOrError()
+		}
+	}
+}
+
+// Kill terminate command started remotely with Stream call.
+func (c *Client) Kill(ctx context.Context, id string) error {
+	return c.cc.Invoke(ctx, "/"+serviceName+"/Kill", &KillRequest{ID: id},
+		&KillResponse{}, grpc.CallContentSubtype(codecName))
+}
@@ -0,0 +1,22 @@
+module github.com/d2r2/go-shell/shellrpc
+
+go 1.26.0
+
+require (
+	github.com/d2r2/go-shell v0.0.0-20261014153635-fd907736a843
+	google.golang.org/grpc v1.84.0
+)
+
+require (
+	golang.org/x/crypto v0.57.0 // indirect
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/term v0.46.0 // indirect
+	golang.org/x/text v0.42.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+// Root module is used from the local tree, while the module is
+// developed; importers resolve the version required above.
+replace github.com/d2r2/go-shell => ../
@@ -0,0 +1,217 @@
+package shellrpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	shell "github.com/d2r2/go-shell"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server is an implementation of gRPC service,
+// which execute commands on the local host.
+type Server struct {
+	// Allow, if not nil, is consulted before every command start,
+	// and command is rejected with PermissionDenied if error returned.
+	Allow func(req *RunRequest) error
+
+	lastID  uint64
+	mu      sync.Mutex
+	running map[string]*shell.App
+}
+
+// NewServer create new service instance.
+func NewServer() *Server {
+	return &Server{running: make(map[string]*shell.App)}
+}
+
+// Register register service in gRPC server.
+func (s *Server) Register(srv *grpc.Server) {
+	srv.RegisterService(&serviceDesc, s)
+}
+
+func (s *Server) newApp(req *RunRequest) (*shell.App, error) {
+	if s.Allow != nil {
+		err := s.Allow(req)
+		if err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "command name is empty")
+	}
+	app := shell.NewApp(req.Name, req.Args...)
+	if len(req.Env) > 0 {
+		app.AddEnvironments(req.Env)
+	}
+	app.SetDir(req.Dir)
+	return app, nil
+}
+
+func exitStatus(st shell.ExitCodeOrError) *ExitStatus {
+	es := &ExitStatus{ExitCode: st.ExitCode}
+	if st.Error != nil {
+		es.Error = st.Error.Error()
+	}
+	return es
+}
+
+// wait wait for application completion, either kill
+// it once context is cancelled (client gone).
+func wait(ctx context.Context, app *shell.App, ch chan shell.ExitCodeOrError) shell.ExitCodeOrError {
+	select {
+	case st, ok := <-ch:
+		if !ok {
+			// Status consumed already by Kill call.
+			return *app.ExitCodeOrError()
+		}
+		return st
+	case <-ctx.Done():
+		app.Kill()
+		return *app.ExitCodeOrError()
+	}
+}
+
+func (s *Server) run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	app, err := s.newApp(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	ch, err := app.Start(bytes.NewReader(req.Stdin), &stdout, &stderr)
+	if err != nil {
+		return &RunResponse{Status: exitStatus(shell.ExitCodeOrError{Error: err})}, nil
+	}
+	st := wait(ctx, app, ch)
+	return &RunResponse{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(),
+		Status: exitStatus(st)}, nil
+}
+
+// streamSender serialize messages sent from stdout and stderr copy routines.
+type streamSender struct {
+	sync.Mutex
+	stream grpc.ServerStream
+}
+
+func (s *streamSender) send(msg *StreamResponse) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.stream.SendMsg(msg)
+}
+
+type streamWriter struct {
+	sender *streamSender
+	stderr bool
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	msg := &StreamResponse{}
+	// Copy data, since it is encoded after Write returned.
+	data := append([]byte{}, p...)
+	if w.stderr {
+		msg.Stderr = data
+	} else {
+		msg.Stdout = data
+	}
+	err := w.sender.send(msg)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *Server) stream(req *RunRequest, stream grpc.ServerStream) error {
+	app, err := s.newApp(req)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatUint(atomic.AddUint64(&s.lastID, 1), 10)
+	sender := &streamSender{stream: stream}
+	err = sender.send(&StreamResponse{ID: id})
+	if err != nil {
+		return err
+	}
+	var stdin io.Reader
+	if len(req.Stdin) > 0 {
+		stdin = bytes.NewReader(req.Stdin)
+	}
+	// App is registered before start, since client know ID already,
+	// while lock isn't held during start, so other calls aren't blocked.
+	s.mu.Lock()
+	s.running[id] = app
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+	}()
+	ch, err := app.Start(stdin, &streamWriter{sender: sender},
+		&streamWriter{sender: sender, stderr: true})
+	if err != nil {
+		return sender.send(&StreamResponse{Status: exitStatus(shell.ExitCodeOrError{Error: err})})
+	}
+	st := wait(stream.Context(), app, ch)
+	return sender.send(&StreamResponse{Status: exitStatus(st)})
+}
+
+func (s *Server) kill(ctx context.Context, req *KillRequest) (*KillResponse, error) {
+	s.mu.Lock()
+	app, ok := s.running[req.ID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "command %q is not running", req.ID)
+	}
+	err := app.Kill()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &KillResponse{}, nil
+}
+
+func runHandler(srv interface{}, ctx context.Context, dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+
+	req := &RunRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(shellService).run(ctx, req.(*RunRequest))
+	}
+	if interceptor == nil {
+		return handler(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Run"}
+	return interceptor(ctx, req, info, handler)
+}
+
+func killHandler(srv interface{}, ctx context.Context, dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+
+	req := &KillRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(shellService).kill(ctx, req.(*KillRequest))
+	}
+	if interceptor == nil {
+		return handler(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Kill"}
+	return interceptor(ctx, req, info, handler)
+}
+
+func streamHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := &RunRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(shellService).stream(req, stream)
+}
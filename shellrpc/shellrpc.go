@@ -0,0 +1,107 @@
+// Package shellrpc implement gRPC service and client, which allow
+// to execute and supervise commands on another machine with the same
+// semantics, as github.com/d2r2/go-shell App provides locally.
+//
+// Service doesn't require protobuf code generation: messages are
+// plain Go structs, encoded with JSON codec registered as gRPC
+// content-subtype "shellrpc-json", which is selected by Client for
+// its calls only, so codecs of other services are not affected
+// (registering plain "json" would replace one used process wide).
+// Service has no authentication on its own, so protect it with TLS
+// credentials and interceptors, either restrict commands with
+// Server.Allow hook.
+package shellrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// RunRequest describe command to execute.
+type RunRequest struct {
+	Name  string   `json:"name"`
+	Args  []string `json:"args,omitempty"`
+	Env   []string `json:"env,omitempty"`
+	Dir   string   `json:"dir,omitempty"`
+	Stdin []byte   `json:"stdin,omitempty"`
+}
+
+// RunResponse contain captured output and exit status of the command.
+type RunResponse struct {
+	Stdout []byte      `json:"stdout,omitempty"`
+	Stderr []byte      `json:"stderr,omitempty"`
+	Status *ExitStatus `json:"status"`
+}
+
+// ExitStatus is a serializable form of shell.ExitCodeOrError.
+type ExitStatus struct {
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// StreamResponse is a message of output stream: first one contain
+// only ID, which can be used with Kill, subsequent ones contain
+// output chunks, and the last one contain exit status.
+type StreamResponse struct {
+	ID     string      `json:"id,omitempty"`
+	Stdout []byte      `json:"stdout,omitempty"`
+	Stderr []byte      `json:"stderr,omitempty"`
+	Status *ExitStatus `json:"status,omitempty"`
+}
+
+// KillRequest identify command started with Stream call.
+type KillRequest struct {
+	ID string `json:"id"`
+}
+
+// KillResponse is an empty reply for Kill call.
+type KillResponse struct {
+}
+
+var errNoStatus = errors.New("stream closed without exit status")
+
+const (
+	serviceName = "shellrpc.Shell"
+	codecName   = "shellrpc-json"
+)
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return codecName
+}
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// shellService is a handler type of gRPC service.
+type shellService interface {
+	run(ctx context.Context, req *RunRequest) (*RunResponse, error)
+	stream(req *RunRequest, stream grpc.ServerStream) error
+	kill(ctx context.Context, req *KillRequest) (*KillResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*shellService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Run", Handler: runHandler},
+		{MethodName: "Kill", Handler: killHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Stream", Handler: streamHandler, ServerStreams: true},
+	},
+}
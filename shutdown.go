@@ -0,0 +1,154 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShutdown is returned by Start once Shutdown has been called.
+var ErrShutdown = errors.New("shell package is shut down")
+
+// DefaultGracePeriod is used by Shutdown for applications
+// started without WithGracePeriod.
+const DefaultGracePeriod = 10 * time.Second
+
+var (
+	supervisedMutex sync.Mutex
+	supervised      = make(map[*App]struct{})
+	shutdown        bool
+	// starting count starts passed shutdown check,
+	// which are not finished yet.
+	starting sync.WaitGroup
+)
+
+// shutdownKillWait is a time Shutdown wait for applications
+// killed once ctx is done, before return.
+const shutdownKillWait = time.Second
+
+// WithGracePeriod set time application is given to exit after
+// SIGTERM (see App.Terminate), once Shutdown is called.
+func WithGracePeriod(grace time.Duration) Option {
+	return func(app *App) {
+		app.gracePeriod = grace
+	}
+}
+
+// Shutdown stop accepting new starts (Start fail with ErrShutdown
+// afterwards, irreversibly), terminate all applications running
+// (started, being started or adopted) concurrently, each with its
+// grace period (see WithGracePeriod), and wait for them to exit.
+// Once ctx is done, applications still running are killed at once;
+// if they don't exit promptly, Shutdown return ctx error without
+// waiting. Applications,
+// which had to be killed, are returned, along with the first error
+// of termination, if any. Wire it into shutdown of the program,
+// so children don't outlive it.
+func Shutdown(ctx context.Context) ([]*App, error) {
+	supervisedMutex.Lock()
+	shutdown = true
+	supervisedMutex.Unlock()
+	// Applications being started, which passed shutdown
+	// check already, are registered once start finished.
+	started := make(chan struct{})
+	go func() {
+		starting.Wait()
+		close(started)
+	}()
+	select {
+	case <-started:
+	case <-ctx.Done():
+	}
+	var mutex sync.Mutex
+	var killed []*App
+	var first error
+	result := func() ([]*App, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]*App{}, killed...), first
+	}
+	// Repeat, since applications adopted
+	// might be registered after snapshot.
+	for {
+		apps := supervisedApps()
+		if len(apps) == 0 {
+			return result()
+		}
+		var wg sync.WaitGroup
+		for _, app := range apps {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				grace := app.gracePeriod
+				if grace <= 0 {
+					grace = DefaultGracePeriod
+				}
+				err := app.terminate(grace, ctx.Done(), func() {
+					mutex.Lock()
+					defer mutex.Unlock()
+					killed = append(killed, app)
+				})
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err != nil && first == nil {
+					first = err
+				}
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			// Let applications killed exit and be reported.
+			select {
+			case <-done:
+			case <-time.After(shutdownKillWait):
+			}
+			list, _ := result()
+			return list, ctx.Err()
+		}
+	}
+}
+
+// checkShutdown fail, if package is shut down, otherwise
+// register start in progress, so Shutdown wait for it;
+// starting.Done must be called, once start finished.
+func checkShutdown() error {
+	supervisedMutex.Lock()
+	defer supervisedMutex.Unlock()
+	if shutdown {
+		return ErrShutdown
+	}
+	starting.Add(1)
+	return nil
+}
+
+// supervise register application started, so Shutdown terminate it.
+func (app *App) supervise() {
+	supervisedMutex.Lock()
+	defer supervisedMutex.Unlock()
+	supervised[app] = struct{}{}
+}
+
+// unsupervise remove application exited from registry.
+func (app *App) unsupervise() {
+	supervisedMutex.Lock()
+	defer supervisedMutex.Unlock()
+	delete(supervised, app)
+}
+
+// supervisedApps return applications running.
+func supervisedApps() []*App {
+	supervisedMutex.Lock()
+	defer supervisedMutex.Unlock()
+	list := make([]*App, 0, len(supervised))
+	for app := range supervised {
+		list = append(list, app)
+	}
+	return list
+}
@@ -0,0 +1,125 @@
+//go:build !windows
+
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// Shutdown is irreversible, so tests of it run in a child process.
+const shutdownTestEnv = "GO_SHELL_SHUTDOWN_TEST"
+
+// runShutdownTest run test in a child process, if not there already,
+// and return true in the child, where test body must be executed.
+func runShutdownTest(t *testing.T) bool {
+	t.Helper()
+	if os.Getenv(shutdownTestEnv) == t.Name() {
+		return true
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), shutdownTestEnv+"="+t.Name())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("child process failed: %v\n%s", err, out)
+	}
+	return false
+}
+
+func TestShutdownTerminate(t *testing.T) {
+	if !runShutdownTest(t) {
+		return
+	}
+	app := NewApp("sleep", "30").With(WithGracePeriod(5 * time.Second))
+	ch, err := app.Start(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	killed, err := Shutdown(ctx)
+	if err != nil || len(killed) != 0 {
+		t.Fatalf("Shutdown: %v, killed %d", err, len(killed))
+	}
+	select {
+	case <-ch:
+	default:
+		t.Fatal("application is running after Shutdown")
+	}
+	if _, err := NewApp("true").Start(nil, nil, nil); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("Start after Shutdown: %v", err)
+	}
+}
+
+func TestShutdownKill(t *testing.T) {
+	if !runShutdownTest(t) {
+		return
+	}
+	// Shell ignoring SIGTERM has to be killed.
+	app := NewApp("sh", "-c", "trap '' TERM; sleep 30 & wait; sleep 30").
+		With(WithGracePeriod(30 * time.Second))
+	if _, err := app.Start(nil, nil, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	killed, _ := Shutdown(ctx)
+	if len(killed) != 1 || killed[0] != app {
+		t.Fatalf("unexpected applications killed %v", killed)
+	}
+	if st := app.Wait(); st.ExitCode == 0 && st.Error == nil {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+}
+
+func TestShutdownStarting(t *testing.T) {
+	if !runShutdownTest(t) {
+		return
+	}
+	// Policy hold start of application, till Shutdown is called.
+	release := make(chan struct{})
+	SetPolicy(PolicyFunc(func(path string, args []string) error {
+		if len(args) > 0 && args[0] == "30" {
+			<-release
+		}
+		return nil
+	}))
+	app := NewApp("sleep", "30").With(WithGracePeriod(5 * time.Second))
+	started := make(chan error, 1)
+	go func() {
+		_, err := app.Start(nil, nil, nil)
+		started <- err
+	}()
+	// Wait for start to reach policy check.
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Shutdown(ctx)
+		done <- err
+	}()
+	for {
+		if _, err := NewApp("true").Start(nil, nil, nil); errors.Is(err, ErrShutdown) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	if err := <-started; err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case <-app.Done():
+	default:
+		t.Fatal("application started during Shutdown is running")
+	}
+}
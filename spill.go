@@ -0,0 +1,109 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// SpillBuffer collect output in memory up to threshold and
+// transparently spill it to temporary file once threshold exceeded,
+// so huge output doesn't exhaust memory. Call Close to remove
+// temporary file, once captured output not needed anymore.
+type SpillBuffer struct {
+	sync.Mutex
+	threshold int64
+	dir       string
+	buf       bytes.Buffer
+	file      *os.File
+	size      int64
+	err       error
+}
+
+// NewSpillBuffer create buffer, which spill data to temporary
+// file in directory dir (system one, if empty) after threshold bytes.
+func NewSpillBuffer(threshold int64, dir string) *SpillBuffer {
+	return &SpillBuffer{threshold: threshold, dir: dir}
+}
+
+// Write implement io.Writer interface.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.file == nil && int64(b.buf.Len()+len(p)) > b.threshold {
+		b.file, b.err = os.CreateTemp(b.dir, "go-shell-*.out")
+		if b.err != nil {
+			return 0, b.err
+		}
+		_, b.err = b.file.Write(b.buf.Bytes())
+		if b.err != nil {
+			return 0, b.err
+		}
+		b.buf = bytes.Buffer{}
+	}
+	var n int
+	if b.file != nil {
+		n, b.err = b.file.Write(p)
+	} else {
+		n, _ = b.buf.Write(p)
+	}
+	b.size += int64(n)
+	return n, b.err
+}
+
+// Size return total amount of data written.
+func (b *SpillBuffer) Size() int64 {
+	b.Lock()
+	defer b.Unlock()
+	return b.size
+}
+
+// Spilled return true, if data has been spilled to disk.
+func (b *SpillBuffer) Spilled() bool {
+	b.Lock()
+	defer b.Unlock()
+	return b.file != nil
+}
+
+// Reader return reader of all data written so far.
+// Reader of spilled data is valid until Close call.
+func (b *SpillBuffer) Reader() (io.ReadSeeker, error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.file == nil {
+		return bytes.NewReader(b.buf.Bytes()), nil
+	}
+	return io.NewSectionReader(b.file, 0, b.size), nil
+}
+
+// Close release memory and remove temporary file.
+func (b *SpillBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+	b.buf = bytes.Buffer{}
+	if b.file == nil {
+		return nil
+	}
+	name := b.file.Name()
+	b.file.Close()
+	b.file = nil
+	return os.Remove(name)
+}
+
+// RunSpilled start application synchronously and capture its stdout
+// and stderr in SpillBuffer ones, which keep up to threshold bytes
+// in memory and spill to temporary files the rest.
+// Caller must Close both buffers, once output not needed anymore.
+func (app *App) RunSpilled(stdin io.Reader, threshold int64) (stdout, stderr *SpillBuffer, st ExitCodeOrError) {
+	stdout = NewSpillBuffer(threshold, "")
+	stderr = NewSpillBuffer(threshold, "")
+	st = app.Run(stdin, stdout, stderr)
+	return stdout, stderr, st
+}
@@ -0,0 +1,196 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHExecutor is a backend which keep SSH client connection
+// used to run applications on the remote host instead of the local one.
+// Only environments added with App.AddEnvironments
+// are passed to the remote process.
+type SSHExecutor struct {
+	client *ssh.Client
+}
+
+// NewSSHExecutor create executor which run applications
+// via SSH client connection established by the caller.
+func NewSSHExecutor(client *ssh.Client) *SSHExecutor {
+	return &SSHExecutor{client: client}
+}
+
+// DialSSH connect to the remote host and return executor
+// bound to this connection. Call Close, once executor not needed anymore.
+func DialSSH(addr string, config *ssh.ClientConfig) (*SSHExecutor, error) {
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewSSHExecutor(client), nil
+}
+
+// Close terminate SSH connection.
+func (e *SSHExecutor) Close() error {
+	return e.client.Close()
+}
+
+// Start implement Executor interface: open new SSH session
+// and run there command line built from cmd path, arguments,
+// working directory and additional environments.
+func (e *SSHExecutor) Start(cmd *exec.Cmd, env []string) (Process, error) {
+	session, err := e.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	session.Stdin = cmd.Stdin
+	session.Stdout = cmd.Stdout
+	session.Stderr = cmd.Stderr
+	err = session.Start(sshCommandLine(cmd, env))
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &sshProcess{session: session}, nil
+}
+
+type sshProcess struct {
+	session *ssh.Session
+	killed  int32
+	signal  os.Signal
+}
+
+// Pid return 0, since SSH protocol doesn't report remote process identifier.
+func (p *sshProcess) Pid() int {
+	return 0
+}
+
+func (p *sshProcess) Wait() (int, error) {
+	defer p.session.Close()
+	err := p.session.Wait()
+	if exiterr, ok := err.(*ssh.ExitError); ok && exiterr.Signal() != "" {
+		p.signal = sshOSSignal(ssh.Signal(exiterr.Signal()))
+	}
+	exitCode, err := sshExitCodeOrError(err)
+	if err != nil && atomic.LoadInt32(&p.killed) != 0 {
+		// Session was closed by Kill before exit status received.
+		exitCode, err = -1, nil
+	}
+	return exitCode, err
+}
+
+func (p *sshProcess) ExitSignal() os.Signal {
+	return p.signal
+}
+
+func (p *sshProcess) Signal(sig os.Signal) error {
+	s, err := sshSignal(sig)
+	if err != nil {
+		return err
+	}
+	return p.session.Signal(s)
+}
+
+func (p *sshProcess) Kill() error {
+	err := p.session.Signal(ssh.SIGKILL)
+	if err != nil {
+		return err
+	}
+	// Remote server might ignore signal request,
+	// so close session to make sure remote process receive SIGHUP.
+	atomic.StoreInt32(&p.killed, 1)
+	return p.session.Close()
+}
+
+// sshCommandLine compose command line to execute by remote shell.
+// Environments are passed via env utility, since most SSH servers
+// decline "env" requests unless explicitly configured with AcceptEnv.
+func sshCommandLine(cmd *exec.Cmd, env []string) string {
+	var b strings.Builder
+	if cmd.Dir != "" {
+		b.WriteString("cd " + QuoteArg(cmd.Dir) + " && ")
+	}
+	b.WriteString("exec")
+	if len(env) > 0 {
+		b.WriteString(" env")
+		for _, item := range env {
+			b.WriteString(" " + QuoteArg(item))
+		}
+	}
+	// Use original name, since cmd.Path is resolved against local PATH.
+	for _, arg := range cmd.Args {
+		b.WriteString(" " + QuoteArg(arg))
+	}
+	return b.String()
+}
+
+// QuoteArg quote argument with single quotes, if necessary,
+// to protect it from interpretation by POSIX shell.
+func QuoteArg(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range arg {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// sshExitCodeOrError convert SSH session completion error
+// to exit code, either error.
+func sshExitCodeOrError(err error) (int, error) {
+	if exiterr, ok := err.(*ssh.ExitError); ok {
+		if exiterr.Signal() != "" {
+			// Follow local convention, where exit status
+			// is -1 for processes terminated by signal.
+			return -1, nil
+		}
+		return exiterr.ExitStatus(), nil
+	}
+	return 0, err
+}
+
+var sshSignals = map[os.Signal]ssh.Signal{
+	syscall.SIGABRT: ssh.SIGABRT,
+	syscall.SIGALRM: ssh.SIGALRM,
+	syscall.SIGFPE:  ssh.SIGFPE,
+	syscall.SIGHUP:  ssh.SIGHUP,
+	syscall.SIGILL:  ssh.SIGILL,
+	syscall.SIGINT:  ssh.SIGINT,
+	syscall.SIGKILL: ssh.SIGKILL,
+	syscall.SIGPIPE: ssh.SIGPIPE,
+	syscall.SIGQUIT: ssh.SIGQUIT,
+	syscall.SIGSEGV: ssh.SIGSEGV,
+	syscall.SIGTERM: ssh.SIGTERM,
+}
+
+// sshSignal map OS signal to the signal name defined by RFC 4254.
+func sshSignal(sig os.Signal) (ssh.Signal, error) {
+	if s, ok := sshSignals[sig]; ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("signal %v can't be delivered via SSH", sig)
+}
+
+// sshOSSignal map signal name defined by RFC 4254 to OS signal.
+func sshOSSignal(sig ssh.Signal) os.Signal {
+	for k, v := range sshSignals {
+		if v == sig {
+			return k
+		}
+	}
+	return nil
+}
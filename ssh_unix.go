@@ -0,0 +1,15 @@
+//go:build !windows
+
+package shell
+
+import (
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func init() {
+	// User signals are not defined on Windows.
+	sshSignals[syscall.SIGUSR1] = ssh.SIGUSR1
+	sshSignals[syscall.SIGUSR2] = ssh.SIGUSR2
+}
@@ -0,0 +1,193 @@
+package shell
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// statsSamples limit durations kept per command for percentiles,
+// which are computed over the most recent runs.
+const statsSamples = 1024
+
+// CommandStats aggregate runs of the command with the same name.
+type CommandStats struct {
+	Name string `json:"name"`
+	// Runs count all runs, including failed to start.
+	Runs int64 `json:"runs"`
+	// Succeeded count runs exited with zero code.
+	Succeeded int64 `json:"succeeded"`
+	// Failed count runs exited with non-zero code or error.
+	Failed int64 `json:"failed"`
+	// StartFailed count runs failed to start.
+	StartFailed int64 `json:"startFailed"`
+	// Killed count Kill calls.
+	Killed      int64   `json:"killed"`
+	SuccessRate float64 `json:"successRate"`
+	// Durations are reported in milliseconds by MarshalJSON.
+	Total time.Duration `json:"-"`
+	P50   time.Duration `json:"-"`
+	P90   time.Duration `json:"-"`
+	P99   time.Duration `json:"-"`
+	Max   time.Duration `json:"-"`
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (st CommandStats) MarshalJSON() ([]byte, error) {
+	type stats CommandStats
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return json.Marshal(&struct {
+		stats
+		TotalMs float64 `json:"totalMs"`
+		P50Ms   float64 `json:"p50Ms"`
+		P90Ms   float64 `json:"p90Ms"`
+		P99Ms   float64 `json:"p99Ms"`
+		MaxMs   float64 `json:"maxMs"`
+	}{stats(st), ms(st.Total), ms(st.P50), ms(st.P90), ms(st.P99), ms(st.Max)})
+}
+
+type commandStats struct {
+	CommandStats
+	samples []time.Duration
+	next    int
+}
+
+// Stats aggregate run counts, success rates and duration percentiles
+// of every application started by the package, keyed by application
+// name, for the lifetime of the process. Stats register itself as
+// Observer; call Close to stop collecting.
+type Stats struct {
+	mutex    sync.Mutex
+	commands map[string]*commandStats
+}
+
+// NewStats create statistics collector.
+func NewStats() *Stats {
+	s := &Stats{commands: make(map[string]*commandStats)}
+	AddObserver(s)
+	return s
+}
+
+// Close unregister collector from observers.
+func (s *Stats) Close() error {
+	RemoveObserver(s)
+	return nil
+}
+
+// Reset drop collected statistics.
+func (s *Stats) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.commands = make(map[string]*commandStats)
+}
+
+// Command return statistics of the command, false if it never ran.
+func (s *Stats) Command(name string) (CommandStats, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c, ok := s.commands[name]
+	if !ok {
+		return CommandStats{}, false
+	}
+	return c.snapshot(), true
+}
+
+// Snapshot return statistics of all commands sorted by name.
+func (s *Stats) Snapshot() []CommandStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]CommandStats, 0, len(s.commands))
+	for _, c := range s.commands {
+		list = append(list, c.snapshot())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (s *Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Snapshot())
+}
+
+// WriteJSON dump statistics to w as indented JSON.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Snapshot())
+}
+
+func (s *Stats) get(name string) *commandStats {
+	c, ok := s.commands[name]
+	if !ok {
+		c = &commandStats{CommandStats: CommandStats{Name: name}}
+		s.commands[name] = c
+	}
+	return c
+}
+
+func (c *commandStats) snapshot() CommandStats {
+	st := c.CommandStats
+	if finished := st.Succeeded + st.Failed + st.StartFailed; finished > 0 {
+		st.SuccessRate = float64(st.Succeeded) / float64(finished)
+	}
+	if len(c.samples) > 0 {
+		sorted := append([]time.Duration{}, c.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		percentile := func(p float64) time.Duration {
+			return sorted[int(p*float64(len(sorted)-1)+0.5)]
+		}
+		st.P50, st.P90, st.P99 = percentile(0.5), percentile(0.9), percentile(0.99)
+	}
+	return st
+}
+
+func (c *commandStats) observe(d time.Duration) {
+	c.Total += d
+	if d > c.Max {
+		c.Max = d
+	}
+	if len(c.samples) < statsSamples {
+		c.samples = append(c.samples, d)
+		return
+	}
+	c.samples[c.next] = d
+	c.next = (c.next + 1) % statsSamples
+}
+
+// Started implement Observer interface.
+func (s *Stats) Started(app *App) {
+}
+
+// Failed implement Observer interface.
+func (s *Stats) Failed(app *App, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := s.get(app.Name())
+	c.Runs++
+	c.StartFailed++
+}
+
+// Exited implement Observer interface.
+func (s *Stats) Exited(app *App, st ExitCodeOrError, duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := s.get(app.Name())
+	c.Runs++
+	if st.Error == nil && st.ExitCode == 0 {
+		c.Succeeded++
+	} else {
+		c.Failed++
+	}
+	c.observe(duration)
+}
+
+// Killed implement Observer interface.
+func (s *Stats) Killed(app *App) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.get(app.Name()).Killed++
+}
@@ -0,0 +1,253 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SetStdinString set string to be passed to application stdin,
+// when Run/Start called with nil stdin.
+func (app *App) SetStdinString(s string) {
+	app.SetStdinBytes([]byte(s))
+}
+
+// SetStdinBytes set data to be passed to application stdin,
+// when Run/Start called with nil stdin.
+func (app *App) SetStdinBytes(b []byte) {
+	app.stdinData = b
+	app.stdinFile = ""
+}
+
+// SetStdinFile set file to be passed to application stdin,
+// when Run/Start called with nil stdin. File is opened
+// on every start and closed once application exits.
+func (app *App) SetStdinFile(path string) {
+	app.stdinFile = path
+	app.stdinData = nil
+}
+
+// defaultStdin return stdin configured with SetStdin* or FeedStdin methods,
+// or nil if nothing configured.
+func (app *App) defaultStdin() (io.Reader, error) {
+	if app.socketStdin != nil {
+		return app.socketStdin, nil
+	}
+	if app.stdinFeed != nil {
+		// Unblock feeding routine once application exits.
+		app.closers = append(app.closers, app.stdinFeed)
+		return app.stdinFeed, nil
+	}
+	if app.stdinFile != "" {
+		file, err := os.Open(app.stdinFile)
+		if err != nil {
+			return nil, err
+		}
+		app.closers = append(app.closers, file)
+		return file, nil
+	}
+	if app.stdinData != nil {
+		return bytes.NewReader(app.stdinData), nil
+	}
+	return nil, nil
+}
+
+// nullStdin open null device for stdin passed as Discard explicitly,
+// so it's not replaced by stdin configured with SetStdin* methods
+// or inherited by default (see WithDefaultStream).
+func (app *App) nullStdin() (io.Reader, error) {
+	file, err := os.Open(os.DevNull)
+	if err != nil {
+		return nil, err
+	}
+	app.closers = append(app.closers, file)
+	return file, nil
+}
+
+// FeedStdin return channel, which chunks sent to are written to
+// application stdin as they arrive, used when Run/Start called with nil
+// stdin. Send blocks, while application is slow to consume data.
+// Closing channel close application stdin. Once application exits,
+// further chunks are discarded, so sender never blocks forever.
+// Must be called before Start.
+func (app *App) FeedStdin() chan<- []byte {
+	ch := make(chan []byte)
+	r, w := io.Pipe()
+	go func() {
+		defer w.Close()
+		for chunk := range ch {
+			_, err := w.Write(chunk)
+			if err != nil {
+				// Application exited, so drain channel till closed.
+				for range ch {
+				}
+				return
+			}
+		}
+	}()
+	app.stdinFeed = r
+	app.stdinFeedW = w
+	return ch
+}
+
+// ErrStdinNotClosable is returned by CloseStdin, when stdin
+// is a file, which descriptor is passed directly to the process,
+// or application was started without WithClosableStdin.
+var ErrStdinNotClosable = errors.New("stdin is passed to the process directly and can't be closed")
+
+// WithClosableStdin allow to close application stdin with CloseStdin
+// at any moment. Stdin, which is not a file, is copied to the process
+// via extra OS pipe then, instead of passing it to exec.Cmd as is.
+func WithClosableStdin() Option {
+	return func(app *App) {
+		app.closableStdin = true
+	}
+}
+
+// stdinGate copy stdin to the process via OS pipe,
+// which can be closed at any moment. Chunk being
+// copied at the moment of close is delivered.
+// Process read pipe descriptor directly, so exec.Cmd
+// doesn't wait for the source, which never reach EOF.
+type stdinGate struct {
+	sync.Mutex
+	r        *os.File
+	w        *os.File
+	closed   bool
+	wclosed  bool
+	writing  bool
+	readDone chan struct{}
+}
+
+// stdinCloseGrace is a time to wait for the chunk,
+// which might be just read from source, on close.
+const stdinCloseGrace = 50 * time.Millisecond
+
+func newStdinGate(src io.Reader) (*stdinGate, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	g := &stdinGate{r: r, w: w}
+	go g.copy(src)
+	return g, nil
+}
+
+func (g *stdinGate) copy(src io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		g.Lock()
+		g.readDone = make(chan struct{})
+		g.Unlock()
+		// Routine might stay blocked reading src after close,
+		// till src produce data or EOF.
+		n, err := src.Read(buf)
+		g.Lock()
+		close(g.readDone)
+		g.readDone = nil
+		if g.wclosed {
+			g.Unlock()
+			return
+		}
+		g.writing = true
+		g.Unlock()
+		var werr error
+		if n > 0 {
+			_, werr = g.w.Write(buf[:n])
+		}
+		g.Lock()
+		g.writing = false
+		if g.closed || werr != nil || err != nil {
+			g.w.Close()
+			g.wclosed = true
+			g.Unlock()
+			return
+		}
+		g.Unlock()
+	}
+}
+
+func (g *stdinGate) close() {
+	g.Lock()
+	if g.closed {
+		g.Unlock()
+		return
+	}
+	g.closed = true
+	done := g.readDone
+	g.Unlock()
+	if done != nil {
+		// Give a chance to deliver chunk, which source
+		// might have just passed to the routine.
+		select {
+		case <-done:
+		case <-time.After(stdinCloseGrace):
+		}
+	}
+	g.Lock()
+	defer g.Unlock()
+	if !g.writing && !g.wclosed {
+		g.w.Close()
+		g.wclosed = true
+	}
+}
+
+// CloseStdin close application stdin, so tools like cat or sort,
+// which never exit until stdin reach EOF, can finish.
+// Return ErrStdinNotClosable, if stdin is *os.File, since
+// its descriptor is inherited by the process directly,
+// or if application was started without WithClosableStdin.
+// Do nothing, if application was started without stdin.
+func (app *App) CloseStdin() error {
+	app.mutex.Lock()
+	gate, file, ungated := app.stdinGate, app.stdinIsFile, app.stdinUngated
+	app.mutex.Unlock()
+	if file || ungated {
+		return ErrStdinNotClosable
+	}
+	if gate != nil {
+		gate.close()
+	}
+	return nil
+}
+
+// CloseStdinAfter close application stdin after duration, counted
+// from the start, if called before Start, or from now otherwise.
+// Being called before Start, imply WithClosableStdin.
+func (app *App) CloseStdinAfter(d time.Duration) {
+	if app.getProcess() == nil {
+		app.closeStdinAfter = d
+		app.closableStdin = true
+		return
+	}
+	time.AfterFunc(d, func() { app.CloseStdin() })
+}
+
+// gateStdin wrap stdin to allow closing it at any moment,
+// if requested by WithClosableStdin or CloseStdinAfter,
+// or if stdin is a pipe fed by the package itself.
+func (app *App) gateStdin(stdin io.Reader, own bool) (io.Reader, error) {
+	if _, ok := stdin.(*os.File); ok {
+		app.stdinIsFile = true
+		return stdin, nil
+	}
+	if !app.closableStdin && !own {
+		app.mutex.Lock()
+		app.stdinUngated = true
+		app.mutex.Unlock()
+		return stdin, nil
+	}
+	gate, err := newStdinGate(stdin)
+	if err != nil {
+		return nil, err
+	}
+	app.mutex.Lock()
+	app.stdinGate = gate
+	app.mutex.Unlock()
+	// Unblock copying routine once application exits.
+	app.closers = append(app.closers, gate.r)
+	return gate.r, nil
+}
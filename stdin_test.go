@@ -0,0 +1,54 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCloseStdin(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("data"))
+	var stdout bytes.Buffer
+	app := NewApp("cat").With(WithClosableStdin())
+	ch, err := app.Start(r, &stdout, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := app.CloseStdin(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case st := <-ch:
+		if st.Error != nil || st.ExitCode != 0 {
+			t.Fatalf("unexpected exit status %+v", st)
+		}
+	case <-time.After(5 * time.Second):
+		app.Kill()
+		t.Fatal("application didn't exit on closed stdin")
+	}
+	if stdout.String() != "data" {
+		t.Fatalf("unexpected stdout %q", stdout.String())
+	}
+}
+
+func TestCloseStdinNotClosable(t *testing.T) {
+	r, w := io.Pipe()
+	app := NewApp("cat")
+	ch, err := app.Start(r, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.CloseStdin(); err != ErrStdinNotClosable {
+		t.Fatalf("unexpected error %v", err)
+	}
+	w.Close()
+	if st := <-ch; st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+}
@@ -0,0 +1,229 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Backpressure define what to do, when consumer of line stream
+// (see StartLines) can't keep up with application output.
+type Backpressure int
+
+const (
+	// BlockChild stop reading output, once queue is full,
+	// so application block writing it. Nothing is lost.
+	BlockChild Backpressure = iota
+	// DropOldest discard the oldest queued line to make room for the new
+	// one, so consumer always see the most recent output (UI and so on).
+	DropOldest
+	// SpillToDisk queue lines exceeding capacity in temporary file,
+	// so neither application is blocked, nor output is lost (archival).
+	SpillToDisk
+)
+
+// DefaultStreamCapacity is a number of lines queued in memory
+// for stream, if StreamPolicy.Capacity is not specified.
+const DefaultStreamCapacity = 256
+
+// StreamPolicy configure line stream of StartLines.
+type StreamPolicy struct {
+	Backpressure Backpressure
+	// Capacity is a number of lines queued in memory.
+	Capacity int
+	// Dir is a directory of temporary file for SpillToDisk
+	// (system one, if empty).
+	Dir string
+}
+
+// StartLines start application and return channels delivering
+// lines of stdout and stderr (without line terminator) as they
+// arrive, handling slow consumer according to policy of every stream.
+// Channels are closed, once application exits and queued lines are
+// delivered; exit status is returned by Wait. Both channels must be
+// drained, unless their policy is DropOldest.
+func (app *App) StartLines(stdin io.Reader, stdout, stderr StreamPolicy) (<-chan string, <-chan string, error) {
+	outQ, errQ := newLineQueue(stdout), newLineQueue(stderr)
+	outW, errW := &queueWriter{q: outQ}, &queueWriter{q: errQ}
+	_, err := app.Start(stdin, outW, errW)
+	if err != nil {
+		outQ.close()
+		errQ.close()
+		return nil, nil, err
+	}
+	outCh, errCh := make(chan string), make(chan string)
+	go outQ.pump(outCh)
+	go errQ.pump(errCh)
+	go func() {
+		app.wait()
+		outW.flush()
+		errW.flush()
+		outQ.close()
+		errQ.close()
+	}()
+	return outCh, errCh, nil
+}
+
+// queueWriter split output into lines pushed to queue.
+type queueWriter struct {
+	q   *lineQueue
+	buf []byte
+}
+
+func (w *queueWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		w.q.push(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	w.buf = append([]byte{}, w.buf...)
+	return len(p), nil
+}
+
+// flush push incomplete last line.
+func (w *queueWriter) flush() {
+	if len(w.buf) > 0 {
+		w.q.push(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// lineQueue is a bounded queue of lines with backpressure policy.
+type lineQueue struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	policy   StreamPolicy
+	lines    []string
+	closed   bool
+	file     *os.File
+	rfile    *os.File
+	reader   *bufio.Reader
+	spilled  int
+	spillErr error
+}
+
+func newLineQueue(policy StreamPolicy) *lineQueue {
+	if policy.Capacity <= 0 {
+		policy.Capacity = DefaultStreamCapacity
+	}
+	q := &lineQueue{policy: policy}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+func (q *lineQueue) push(line string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	switch q.policy.Backpressure {
+	case BlockChild:
+		for len(q.lines) >= q.policy.Capacity && !q.closed {
+			q.cond.Wait()
+		}
+	case DropOldest:
+		if len(q.lines) >= q.policy.Capacity {
+			q.lines = q.lines[1:]
+		}
+	case SpillToDisk:
+		if q.spilled > 0 || len(q.lines) >= q.policy.Capacity {
+			if q.spill(line) {
+				q.cond.Broadcast()
+				return
+			}
+		}
+	}
+	if q.closed {
+		return
+	}
+	q.lines = append(q.lines, line)
+	q.cond.Broadcast()
+}
+
+// spill append line to temporary file, keeping order: once
+// spilled, lines go to file till it's drained. Return false,
+// if file can't be written, so line is kept in memory.
+func (q *lineQueue) spill(line string) bool {
+	if q.spillErr != nil {
+		return false
+	}
+	if q.file == nil {
+		q.file, q.spillErr = os.CreateTemp(q.policy.Dir, "go-shell-*.lines")
+		if q.spillErr != nil {
+			return false
+		}
+		q.rfile, q.spillErr = os.Open(q.file.Name())
+		if q.spillErr != nil {
+			return false
+		}
+		q.reader = bufio.NewReader(q.rfile)
+	}
+	_, q.spillErr = q.file.WriteString(line + "\n")
+	if q.spillErr != nil {
+		return false
+	}
+	q.spilled++
+	return true
+}
+
+// pop return the next line, blocking till it's available,
+// or false, once queue is closed and empty.
+func (q *lineQueue) pop() (string, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.lines) == 0 && q.spilled == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.lines) > 0 {
+		line := q.lines[0]
+		q.lines = q.lines[1:]
+		q.cond.Broadcast()
+		return line, true
+	}
+	if q.spilled > 0 {
+		line, err := q.reader.ReadString('\n')
+		if err == nil {
+			q.spilled--
+			return strings.TrimSuffix(line, "\n"), true
+		}
+		q.spilled = 0
+	}
+	return "", false
+}
+
+func (q *lineQueue) pump(ch chan<- string) {
+	defer close(ch)
+	defer q.release()
+	for {
+		line, ok := q.pop()
+		if !ok {
+			return
+		}
+		ch <- line
+	}
+}
+
+func (q *lineQueue) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// release remove temporary file.
+func (q *lineQueue) release() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.rfile != nil {
+		q.rfile.Close()
+	}
+	if q.file != nil {
+		q.file.Close()
+		os.Remove(q.file.Name())
+	}
+}
@@ -0,0 +1,142 @@
+package shell
+
+import (
+	"io"
+	"os"
+)
+
+// Stream is a sentinel, which can be passed to Run/Start instead
+// of stdin reader or stdout/stderr writer to express intent explicitly.
+type Stream int
+
+const (
+	// Discard connect stream to null device: input is empty,
+	// output is dropped. This is what nil means by default, but
+	// stream passed as Discard is never replaced with the one
+	// configured by SetStdin* methods or WithDefaultStream.
+	Discard Stream = iota + 1
+	// Inherit connect stream to the same stream of the calling
+	// process, which descriptor is passed directly.
+	Inherit
+)
+
+// Read implement io.Reader, so Stream can be passed as stdin.
+// Never called, since Start replace sentinels.
+func (s Stream) Read(p []byte) (int, error) {
+	if s == Inherit {
+		return os.Stdin.Read(p)
+	}
+	return 0, io.EOF
+}
+
+// Write implement io.Writer, so Stream can be passed as stdout
+// or stderr. Never called, since Start replace sentinels.
+func (s Stream) Write(p []byte) (int, error) {
+	if s == Inherit {
+		return os.Stdout.Write(p)
+	}
+	return len(p), nil
+}
+
+// WithDefaultStream choose what nil stream passed to Run/Start mean:
+// Discard (the default) or Inherit. Streams configured otherwise
+// (SetStdin*, FIFO, Unix socket and so on) are not affected.
+func WithDefaultStream(s Stream) Option {
+	return func(app *App) {
+		app.defaultStream = s
+	}
+}
+
+// StdioProfile is a reusable stdio configuration, which is used by
+// Run/Start, when nil stream is passed, so stdio can be set up once
+// instead of passing writers to every call (for instance, by code
+// restarting applications). Streams might be Discard or Inherit.
+// Writer shared by applications running concurrently must be safe
+// for concurrent use. Stdin reader is consumed by the first run;
+// use SetStdin* to pass the same input to every run.
+type StdioProfile struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// InheritStdio connect all streams to the ones of the calling process.
+var InheritStdio = StdioProfile{Stdin: Inherit, Stdout: Inherit, Stderr: Inherit}
+
+// WithStdio set default streams of application, which are kept by Clone.
+func WithStdio(p StdioProfile) Option {
+	return func(app *App) {
+		app.stdio = p
+	}
+}
+
+// WithStdout set default stdout writer of application, see WithStdio.
+func WithStdout(w io.Writer) Option {
+	return func(app *App) {
+		app.stdio.Stdout = w
+	}
+}
+
+// WithStderr set default stderr writer of application, see WithStdio.
+func WithStderr(w io.Writer) Option {
+	return func(app *App) {
+		app.stdio.Stderr = w
+	}
+}
+
+// profileStreams replace nil streams with the ones of stdio profile.
+func (app *App) profileStreams(stdin io.Reader, stdout, stderr io.Writer) (io.Reader, io.Writer, io.Writer) {
+	if stdin == nil {
+		stdin = app.stdio.Stdin
+	}
+	if stdout == nil {
+		stdout = app.stdio.Stdout
+	}
+	if stderr == nil {
+		stderr = app.stdio.Stderr
+	}
+	return stdin, stdout, stderr
+}
+
+// resolveStreams replace sentinels with streams they stand for.
+func resolveStreams(stdin io.Reader, stdout, stderr io.Writer) (io.Reader, io.Writer, io.Writer) {
+	switch stdin {
+	case Discard:
+		stdin = nil
+	case Inherit:
+		stdin = os.Stdin
+	}
+	switch stdout {
+	case Discard:
+		stdout = nil
+	case Inherit:
+		stdout = os.Stdout
+	}
+	switch stderr {
+	case Discard:
+		stderr = nil
+	case Inherit:
+		stderr = os.Stderr
+	}
+	return stdin, stdout, stderr
+}
+
+// inheritDefaults replace nil streams with streams of the calling
+// process, if configured so, besides outputs passed as Discard.
+func (app *App) inheritDefaults(stdin io.Reader, stdout, stderr io.Writer,
+	discardStdout, discardStderr bool) (io.Reader, io.Writer, io.Writer) {
+
+	if app.defaultStream != Inherit {
+		return stdin, stdout, stderr
+	}
+	if stdin == nil && app.stdinFIFO == "" && app.unixSocket == "" {
+		stdin = os.Stdin
+	}
+	if stdout == nil && !discardStdout {
+		stdout = os.Stdout
+	}
+	if stderr == nil && !discardStderr {
+		stderr = os.Stderr
+	}
+	return stdin, stdout, stderr
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscardStdin(t *testing.T) {
+	app := NewApp("cat")
+	app.SetStdinString("data")
+	var stdout bytes.Buffer
+	if st := app.Run(Discard, &stdout, nil); st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("stdin configured replaced Discard: %q", stdout.String())
+	}
+}
+
+func TestDiscardInheritDefault(t *testing.T) {
+	// Capture stdout and stderr of the calling process.
+	path := filepath.Join(t.TempDir(), "out")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = file, file
+	st := NewApp("sh", "-c", "echo LEAKED; echo LEAKED >&2").
+		With(WithDefaultStream(Inherit)).Run(nil, Discard, Discard)
+	os.Stdout, os.Stderr = stdout, stderr
+	if st.Error != nil || st.ExitCode != 0 {
+		t.Fatalf("unexpected exit status %+v", st)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("discarded output is inherited: %q", data)
+	}
+}
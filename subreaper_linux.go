@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const prSetChildSubreaper = 36
+
+// reaperScanPeriod is a period of process table scan, which
+// collect descendants of running applications and reap
+// zombies missed, since signals are coalesced.
+const reaperScanPeriod = time.Second
+
+// EnableSubreaper make the calling process a child subreaper, so
+// descendants of applications, orphaned when their parent exits
+// (for instance, daemons double forking), are re-parented to it
+// instead of init, and don't escape supervision: Kill and Terminate
+// find them, as well as Children does, while their parent is alive.
+// Adopted processes are reaped once they exit, so they don't remain
+// zombies. Only processes, which belong to process group of some
+// application, or have been seen as descendants of application
+// are reaped, so exit status of processes started otherwise
+// is not stolen. Descendants are discovered scanning process table
+// every second and on SIGCHLD, so process, which leave application
+// group and become orphan faster, stay zombie. Subreaper is a process
+// wide attribute, which can't be disabled.
+func EnableSubreaper() error {
+	reaper.Lock()
+	defer reaper.Unlock()
+	if reaper.enabled {
+		return nil
+	}
+	err := prctl(prSetChildSubreaper, 1)
+	if err != nil {
+		return os.NewSyscallError("prctl", err)
+	}
+	reaper.enabled = true
+	reaper.apps = make(map[*App]int)
+	reaper.groups = make(map[int]bool)
+	reaper.seen = make(map[int]uint64)
+	AddObserver(reaperObserver{})
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGCHLD)
+	go reaper.loop(ch)
+	return nil
+}
+
+var reaper subreaper
+
+type subreaper struct {
+	sync.Mutex
+	enabled bool
+	// apps running with their pids.
+	apps map[*App]int
+	// groups of applications ever started, while alive.
+	groups map[int]bool
+	// seen are descendants of applications with start time.
+	seen map[int]uint64
+}
+
+func (r *subreaper) loop(ch chan os.Signal) {
+	ticker := time.NewTicker(reaperScanPeriod)
+	for {
+		select {
+		case <-ch:
+		case <-ticker.C:
+		}
+		r.scan()
+	}
+}
+
+// scan update descendants of running applications
+// and reap adopted zombies.
+func (r *subreaper) scan() {
+	table, err := processTable()
+	if err != nil {
+		return
+	}
+	self := os.Getpid()
+	r.Lock()
+	defer r.Unlock()
+	running := make(map[int]bool, len(r.apps))
+	for _, pid := range r.apps {
+		running[pid] = true
+		for _, p := range descendantsOf(table, pid) {
+			r.seen[p.Pid] = p.start
+		}
+	}
+	alive := make(map[int]bool, len(table))
+	groups := make(map[int]bool)
+	for _, p := range table {
+		alive[p.Pid] = true
+		groups[p.pgid] = true
+		if p.PPid != self || p.alive || running[p.Pid] {
+			continue
+		}
+		start, seen := r.seen[p.Pid]
+		seen = seen && start == p.start
+		// Application process is a group leader, which is never reaped here.
+		if seen || p.Pid != p.pgid && r.groups[p.pgid] {
+			var status syscall.WaitStatus
+			syscall.Wait4(p.Pid, &status, syscall.WNOHANG, nil)
+		}
+	}
+	for pid := range r.seen {
+		if !alive[pid] {
+			delete(r.seen, pid)
+		}
+	}
+	for pgid := range r.groups {
+		if !groups[pgid] && !running[pgid] {
+			delete(r.groups, pgid)
+		}
+	}
+}
+
+// reaperObserver track applications running.
+type reaperObserver struct{}
+
+func (reaperObserver) Started(app *App) {
+	if app.executor != LocalExecutor {
+		return
+	}
+	pid := app.Pid()
+	reaper.Lock()
+	reaper.apps[app] = pid
+	reaper.groups[pid] = true
+	reaper.Unlock()
+}
+
+func (reaperObserver) Failed(app *App, err error) {
+}
+
+func (reaperObserver) Exited(app *App, st ExitCodeOrError, duration time.Duration) {
+	reaper.Lock()
+	delete(reaper.apps, app)
+	reaper.Unlock()
+}
+
+func (reaperObserver) Killed(app *App) {
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package shell
+
+import "errors"
+
+// EnableSubreaper is supported on Linux only.
+func EnableSubreaper() error {
+	return errors.New("subreaper is not supported on this platform")
+}
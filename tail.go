@@ -0,0 +1,115 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// WithTailBuffer make application to retain last lines of stdout
+// and stderr in the ring buffer of size specified, even when output
+// isn't otherwise captured, so failure reports can include recent
+// context cheaply. Retained lines are returned by App.TailLines.
+func WithTailBuffer(lines int) Option {
+	return func(app *App) {
+		app.tailSize = lines
+	}
+}
+
+// TailLines return up to n last lines of stdout and stderr
+// (in order of arrival) retained by ring buffer, configured
+// with WithTailBuffer option; nil, if option is not set.
+func (app *App) TailLines(n int) []string {
+	if app.tail == nil {
+		return nil
+	}
+	return app.tail.last(n)
+}
+
+// lineRing keep last lines written by several streams.
+type lineRing struct {
+	sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func newLineRing(size int) *lineRing {
+	return &lineRing{lines: make([]string, size)}
+}
+
+func (r *lineRing) add(line string) {
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *lineRing) last(n int) []string {
+	r.Lock()
+	defer r.Unlock()
+	count := r.next
+	if r.full {
+		count = len(r.lines)
+	}
+	if n > count || n < 0 {
+		n = count
+	}
+	list := make([]string, 0, n)
+	for i := n; i > 0; i-- {
+		list = append(list, r.lines[(r.next-i+len(r.lines))%len(r.lines)])
+	}
+	return list
+}
+
+// stream return writer, which split stream into lines.
+func (r *lineRing) stream() *lineRingWriter {
+	return &lineRingWriter{ring: r}
+}
+
+type lineRingWriter struct {
+	ring    *lineRing
+	partial []byte
+}
+
+func (w *lineRingWriter) Write(p []byte) (int, error) {
+	w.ring.Lock()
+	defer w.ring.Unlock()
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			break
+		}
+		w.partial = append(w.partial, data[:i]...)
+		w.ring.add(string(bytes.TrimSuffix(w.partial, []byte{'\r'})))
+		w.partial = w.partial[:0]
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial, data...)
+	return len(p), nil
+}
+
+// Flush retain incomplete last line.
+func (w *lineRingWriter) Flush() error {
+	w.ring.Lock()
+	defer w.ring.Unlock()
+	if len(w.partial) > 0 {
+		w.ring.add(string(w.partial))
+		w.partial = nil
+	}
+	return nil
+}
+
+// tailWriters create ring buffer, if configured,
+// and return its writers for both streams.
+func (app *App) tailWriters() (io.Writer, io.Writer, error) {
+	if app.tailSize <= 0 {
+		return nil, nil, nil
+	}
+	app.tail = newLineRing(app.tailSize)
+	out, err := app.tail.stream(), app.tail.stream()
+	app.flushers = append(app.flushers, out, err)
+	return out, err, nil
+}
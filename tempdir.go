@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+)
+
+// WithTempDir create private temporary directory for every run of
+// application, export it as TMPDIR (as well as TMP and TEMP, honored
+// on Windows) and remove it with all content once application exits,
+// so scratch files of external tools are contained and cleaned up.
+// See also KeepTempDirOnFailure. Supported by local executor only.
+func WithTempDir() Option {
+	return func(app *App) {
+		app.tempDir = true
+	}
+}
+
+// KeepTempDirOnFailure enable WithTempDir, but keep directory, if
+// application exited with error or non-zero exit code, to investigate
+// files left there (see App.TempDir). Directory is removed anyway,
+// if application failed to start.
+func KeepTempDirOnFailure() Option {
+	return func(app *App) {
+		app.tempDir = true
+		app.tempDirKeep = true
+	}
+}
+
+// TempDir return temporary directory of the last run
+// created by WithTempDir, empty if none.
+func (app *App) TempDir() string {
+	return app.tempDirRun
+}
+
+// makeTempDir create temporary directory of the run.
+func (app *App) makeTempDir() error {
+	if !app.tempDir {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", app.Name()+"-")
+	if err != nil {
+		return err
+	}
+	app.tempDirRun = dir
+	return nil
+}
+
+// tempDirCmd return copy of cmd with temporary directory exported.
+func (app *App) tempDirCmd(cmd *exec.Cmd) *exec.Cmd {
+	if app.tempDirRun == "" {
+		return cmd
+	}
+	c := *cmd
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	dir := app.tempDirRun
+	// The last entry win, when duplicated.
+	c.Env = append(append([]string{}, base...), "TMPDIR="+dir, "TMP="+dir, "TEMP="+dir)
+	return &c
+}
+
+// removeTempDir remove temporary directory of the run,
+// unless it should be kept, since application failed.
+func (app *App) removeTempDir(failed bool) {
+	if app.tempDirRun == "" || failed && app.tempDirKeep {
+		return
+	}
+	os.RemoveAll(app.tempDirRun)
+}
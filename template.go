@@ -0,0 +1,57 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// WithArgTemplate make Start to render arguments (executable name
+// excluded) as text/template against data, so the same command
+// specification can be reused with different parameters:
+//
+//	app := shell.NewApp("tar", "-czf", "{{.Name}}.tgz", "{{.Dir}}")
+//	app.WithArgTemplate(map[string]string{"Name": "backup", "Dir": "/srv"})
+//
+// Missing keys make Start to fail. Clone keep templates, so clone
+// can be rendered with another data.
+func (app *App) WithArgTemplate(data interface{}) *App {
+	app.argData = data
+	app.argTemplated = true
+	return app
+}
+
+// saveArgs keep original arguments, before they are
+// rendered or expanded on start, for Clone.
+func (app *App) saveArgs() {
+	if app.origArgs == nil {
+		app.origArgs = app.cmd.Args
+	}
+}
+
+// renderArgs render argument templates.
+func (app *App) renderArgs() error {
+	if !app.argTemplated {
+		return nil
+	}
+	app.saveArgs()
+	args := []string{app.origArgs[0]}
+	for i, arg := range app.origArgs[1:] {
+		if !strings.Contains(arg, "{{") {
+			args = append(args, arg)
+			continue
+		}
+		tmpl, err := template.New("arg").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid template of argument #%d: %v", i, err)
+		}
+		var b strings.Builder
+		err = tmpl.Execute(&b, app.argData)
+		if err != nil {
+			return fmt.Errorf("can't render argument #%d: %v", i, err)
+		}
+		args = append(args, b.String())
+	}
+	app.cmd.Args = args
+	return nil
+}
@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// threadSetup modify attributes of the current OS thread, which
+// are inherited by process forked from it, but can't be expressed
+// with SysProcAttr (bounding capabilities, seccomp filter and so on).
+type threadSetup func() error
+
+// cmdWrapper return command, which run cmd indirectly
+// (via POSIX shell, jexec and so on) keeping its pid.
+type cmdWrapper func(cmd *exec.Cmd) (*exec.Cmd, error)
+
+// startProcess start process with application executor; if thread
+// setup is configured, process is forked from dedicated OS thread
+// prepared by setup functions. Thread is kept alive till application
+// exits (so SysProcAttr.Pdeathsig works as expected) and destroyed then,
+// since its attributes can't be restored.
+func (app *App) startProcess(cmd *exec.Cmd, env []string) (Process, error) {
+	setups := app.threadSetup
+	if len(app.classSetup) > 0 {
+		setups = append(append([]threadSetup{}, setups...), app.classSetup...)
+	}
+	if len(setups) == 0 {
+		return app.executor.Start(cmd, env)
+	}
+	if app.executor != LocalExecutor {
+		return nil, errors.New("thread attributes are supported by local executor only")
+	}
+	type result struct {
+		process Process
+		err     error
+	}
+	ch := make(chan result)
+	release := make(threadRelease)
+	go func() {
+		// Never unlock, so thread is terminated with goroutine.
+		runtime.LockOSThread()
+		for _, setup := range setups {
+			if err := setup(); err != nil {
+				ch <- result{err: err}
+				return
+			}
+		}
+		process, err := app.executor.Start(cmd, env)
+		ch <- result{process, err}
+		if err == nil {
+			<-release
+		}
+	}()
+	r := <-ch
+	if r.err == nil {
+		app.closers = append(app.closers, release)
+	}
+	return r.process, r.err
+}
+
+// threadRelease terminate thread process forked from.
+type threadRelease chan struct{}
+
+func (r threadRelease) Close() error {
+	close(r)
+	return nil
+}
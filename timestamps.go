@@ -0,0 +1,114 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// TimestampedLine is a line of output recorded WithTimestamps.
+type TimestampedLine struct {
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+	// Stamp is Time formatted with layout.
+	Stamp string `json:"stamp"`
+	Text  string `json:"text"`
+}
+
+// WithTimestamps record every line of stdout and stderr with the
+// time its first byte is received, formatted with layout (see
+// time.Format, RFC3339Nano if empty). Lines are returned in order
+// of arrival by App.TimestampedLines and included in App.Report,
+// so latency of long runs can be analyzed afterwards.
+func WithTimestamps(layout string) Option {
+	return func(app *App) {
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		app.timestampLayout = layout
+	}
+}
+
+// TimestampedLines return lines recorded, configured
+// with WithTimestamps option; nil, if option is not set.
+func (app *App) TimestampedLines() []TimestampedLine {
+	app.mutex.Lock()
+	r := app.stamps
+	app.mutex.Unlock()
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	return append([]TimestampedLine{}, r.lines...)
+}
+
+// stampRecorder keep lines of both streams.
+type stampRecorder struct {
+	sync.Mutex
+	layout string
+	lines  []TimestampedLine
+}
+
+func (r *stampRecorder) add(stream string, t time.Time, line []byte) {
+	r.lines = append(r.lines, TimestampedLine{Stream: stream, Time: t,
+		Stamp: t.Format(r.layout),
+		Text:  string(bytes.TrimSuffix(line, []byte{'\r'}))})
+}
+
+type stampWriter struct {
+	r       *stampRecorder
+	stream  string
+	started time.Time
+	partial []byte
+}
+
+func (w *stampWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	w.r.Lock()
+	defer w.r.Unlock()
+	data := p
+	for len(data) > 0 {
+		if len(w.partial) == 0 {
+			w.started = now
+		}
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			w.partial = append(w.partial, data...)
+			break
+		}
+		w.partial = append(w.partial, data[:i]...)
+		w.r.add(w.stream, w.started, w.partial)
+		w.partial = w.partial[:0]
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush record incomplete last line.
+func (w *stampWriter) Flush() error {
+	w.r.Lock()
+	defer w.r.Unlock()
+	if len(w.partial) > 0 {
+		w.r.add(w.stream, w.started, w.partial)
+		w.partial = nil
+	}
+	return nil
+}
+
+// timestampWriters create recorder, if configured,
+// and return its writers for both streams.
+func (app *App) timestampWriters() (io.Writer, io.Writer, error) {
+	if app.timestampLayout == "" {
+		return nil, nil, nil
+	}
+	r := &stampRecorder{layout: app.timestampLayout}
+	app.mutex.Lock()
+	app.stamps = r
+	app.mutex.Unlock()
+	out := &stampWriter{r: r, stream: "stdout"}
+	err := &stampWriter{r: r, stream: "stderr"}
+	app.flushers = append(app.flushers, out, err)
+	return out, err, nil
+}
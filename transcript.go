@@ -0,0 +1,160 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// TranscriptFormat define how Transcript is written.
+type TranscriptFormat int
+
+const (
+	// TranscriptPlain write raw terminal output, as script(1) does;
+	// input is not recorded, since terminal echo it normally.
+	TranscriptPlain TranscriptFormat = iota
+	// TranscriptCast write asciinema cast file (format version 2):
+	// output, input and window resize events with timing, which can be
+	// replayed with "asciinema play".
+	TranscriptCast
+)
+
+// Transcript record interactive session for audit or visual replay.
+// Use WithTranscript for RunTerminal, or Wrap master side of
+// pseudo-terminal returned by StartPTY. Safe for concurrent use.
+type Transcript struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	format  TranscriptFormat
+	start   time.Time
+	partial map[string][]byte
+	err     error
+}
+
+// NewTranscript create transcript of session in terminal of
+// cols x rows size writing to w; cast header is written at once.
+func NewTranscript(w io.Writer, format TranscriptFormat, cols, rows int) *Transcript {
+	t := &Transcript{w: w, format: format, start: time.Now(),
+		partial: make(map[string][]byte)}
+	if format == TranscriptCast {
+		header, _ := json.Marshal(map[string]interface{}{
+			"version": 2, "width": cols, "height": rows,
+			"timestamp": t.start.Unix(),
+		})
+		_, t.err = fmt.Fprintf(w, "%s\n", header)
+	}
+	return t
+}
+
+// Output return writer recording terminal output.
+func (t *Transcript) Output() io.Writer {
+	return transcriptWriter{t, "o"}
+}
+
+// Input return writer recording terminal input.
+func (t *Transcript) Input() io.Writer {
+	return transcriptWriter{t, "i"}
+}
+
+// Resize record change of window size.
+func (t *Transcript) Resize(cols, rows int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.format == TranscriptCast {
+		t.event("r", []byte(strconv.Itoa(cols)+"x"+strconv.Itoa(rows)))
+	}
+}
+
+// Err return the first error of writing transcript.
+func (t *Transcript) Err() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.err
+}
+
+// Wrap return master side of pseudo-terminal, which record
+// data read as output and data written as input.
+func (t *Transcript) Wrap(master io.ReadWriter) io.ReadWriter {
+	return &transcriptConn{master: master, t: t}
+}
+
+func (t *Transcript) record(kind string, p []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.format == TranscriptPlain {
+		if kind == "o" && t.err == nil {
+			_, t.err = t.w.Write(p)
+		}
+		return
+	}
+	// Keep incomplete UTF-8 sequence till the next chunk,
+	// since event data must be valid JSON string.
+	data := append(t.partial[kind], p...)
+	cut := len(data)
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				cut = len(data) - i
+			}
+			break
+		}
+	}
+	t.partial[kind] = append([]byte{}, data[cut:]...)
+	if cut > 0 {
+		t.event(kind, data[:cut])
+	}
+}
+
+// event write cast event; caller hold mutex.
+func (t *Transcript) event(kind string, data []byte) {
+	if t.err != nil {
+		return
+	}
+	elapsed := time.Since(t.start).Seconds()
+	line, _ := json.Marshal([]interface{}{elapsed, kind, string(data)})
+	_, t.err = fmt.Fprintf(t.w, "%s\n", line)
+}
+
+type transcriptWriter struct {
+	t    *Transcript
+	kind string
+}
+
+func (w transcriptWriter) Write(p []byte) (int, error) {
+	w.t.record(w.kind, p)
+	return len(p), nil
+}
+
+type transcriptConn struct {
+	master io.ReadWriter
+	t      *Transcript
+}
+
+func (c *transcriptConn) Read(p []byte) (int, error) {
+	n, err := c.master.Read(p)
+	if n > 0 {
+		c.t.record("o", p[:n])
+	}
+	return n, err
+}
+
+func (c *transcriptConn) Write(p []byte) (int, error) {
+	n, err := c.master.Write(p)
+	if n > 0 {
+		c.t.record("i", p[:n])
+	}
+	return n, err
+}
+
+// WithTranscript record session of RunTerminal to w in format
+// specified (see Transcript).
+func WithTranscript(w io.Writer, format TranscriptFormat) Option {
+	return func(app *App) {
+		app.transcript = w
+		app.transcriptFormat = format
+	}
+}
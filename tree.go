@@ -0,0 +1,140 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// ProcInfo describe process found in the process table.
+type ProcInfo struct {
+	Pid  int
+	PPid int
+	// Name is a command name (truncated by OS to 15 characters on Linux).
+	Name string
+	// State is a process state code, as reported by ps
+	// ("R" running, "S" sleeping, "Z" zombie and so on).
+	State string
+}
+
+// procEntry is a process table record.
+type procEntry struct {
+	ProcInfo
+	pgid  int
+	start uint64 // start time, used to detect pid reuse, if known
+	alive bool   // false for zombie
+}
+
+// SurvivorsError is returned by Kill and Terminate, when some
+// descendants of application (which might have moved to another
+// process group, so group kill missed them) are still running
+// after several attempts to kill them.
+type SurvivorsError struct {
+	Pids []int
+}
+
+func (e *SurvivorsError) Error() string {
+	return fmt.Sprintf("descendant processes %v survived kill", e.Pids)
+}
+
+// descendants return snapshot of all application descendants,
+// or nil, if application is not local or table can't be read.
+func (app *App) descendants() []procEntry {
+	if app.executor != LocalExecutor {
+		return nil
+	}
+	pid := app.Pid()
+	if pid == 0 {
+		return nil
+	}
+	table, err := processTable()
+	if err != nil {
+		return nil
+	}
+	return descendantsOf(table, pid)
+}
+
+// Children return live descendants of application (not only direct
+// children), in breadth-first order, for diagnostics and signaling
+// specific processes. Only LocalExecutor is supported.
+func (app *App) Children() ([]ProcInfo, error) {
+	if app.executor != LocalExecutor {
+		return nil, errors.New("process tree is available for local executor only")
+	}
+	pid := app.Pid()
+	if pid == 0 {
+		return nil, ErrNotStarted
+	}
+	table, err := processTable()
+	if err != nil {
+		return nil, err
+	}
+	var list []ProcInfo
+	for _, p := range descendantsOf(table, pid) {
+		if p.alive {
+			list = append(list, p.ProcInfo)
+		}
+	}
+	return list, nil
+}
+
+// descendantsOf walk process table from pid down.
+func descendantsOf(table []procEntry, pid int) []procEntry {
+	children := make(map[int][]procEntry)
+	for _, p := range table {
+		children[p.PPid] = append(children[p.PPid], p)
+	}
+	var list []procEntry
+	queue := []int{pid}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			list = append(list, child)
+			queue = append(queue, child.Pid)
+		}
+		queue = queue[1:]
+	}
+	return list
+}
+
+// killAttempts and killRetryDelay control
+// how persistently survivors are killed.
+const (
+	killAttempts   = 5
+	killRetryDelay = 20 * time.Millisecond
+)
+
+// killSurvivors make sure, that no processes from snapshot
+// are alive, killing the ones found.
+func killSurvivors(snapshot []procEntry) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	var survivors []int
+	for attempt := 0; attempt < killAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(killRetryDelay)
+		}
+		table, err := processTable()
+		if err != nil {
+			return nil
+		}
+		current := make(map[int]procEntry, len(table))
+		for _, p := range table {
+			current[p.Pid] = p
+		}
+		survivors = survivors[:0]
+		for _, p := range snapshot {
+			c, ok := current[p.Pid]
+			if !ok || !c.alive || c.start != p.start {
+				continue
+			}
+			survivors = append(survivors, p.Pid)
+			signalPid(p.Pid, syscall.SIGKILL)
+		}
+		if len(survivors) == 0 {
+			return nil
+		}
+	}
+	return &SurvivorsError{Pids: survivors}
+}
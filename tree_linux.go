@@ -0,0 +1,47 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// processTable read process table from /proc.
+func processTable() ([]procEntry, error) {
+	dir, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var table []procEntry
+	for _, item := range dir {
+		pid, err := strconv.Atoi(item.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + item.Name() + "/stat")
+		if err != nil {
+			// Process has gone.
+			continue
+		}
+		// Command name might contain spaces and parentheses,
+		// so parse fields after the last one.
+		i := bytes.LastIndexByte(data, ')')
+		j := bytes.IndexByte(data, '(')
+		if i == -1 || j == -1 || j > i {
+			continue
+		}
+		fields := bytes.Fields(data[i+1:])
+		if len(fields) < 20 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(string(fields[1]))
+		pgid, _ := strconv.Atoi(string(fields[2]))
+		start, _ := strconv.ParseUint(string(fields[19]), 10, 64)
+		state := fields[0][0]
+		table = append(table, procEntry{
+			ProcInfo: ProcInfo{Pid: pid, PPid: ppid,
+				Name: string(data[j+1 : i]), State: string(state)},
+			pgid: pgid, start: start, alive: state != 'Z' && state != 'X'})
+	}
+	return table, nil
+}
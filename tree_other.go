@@ -0,0 +1,38 @@
+//go:build !linux
+
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processTable read process table with ps utility.
+func processTable() ([]procEntry, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "pgid=", "-o", "stat=", "-o", "comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var table []procEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		pgid, _ := strconv.Atoi(fields[2])
+		table = append(table, procEntry{
+			ProcInfo: ProcInfo{Pid: pid, PPid: ppid,
+				Name: strings.Join(fields[4:], " "), State: fields[3][:1]},
+			pgid: pgid, alive: !strings.HasPrefix(fields[3], "Z")})
+	}
+	return table, scanner.Err()
+}
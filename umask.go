@@ -0,0 +1,44 @@
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// WithUmask set file mode creation mask of application process, so
+// files it creates get predictable permissions regardless of the umask
+// of the calling process. Since umask is a process wide attribute,
+// which can't be changed safely around fork in Go program, application
+// is started via POSIX shell, which set umask and exec the application.
+func WithUmask(mask int) Option {
+	return func(app *App) {
+		if mask < 0 || mask > 0777 {
+			app.setError(fmt.Errorf("umask %#o out of range", mask))
+			return
+		}
+		app.umask = mask
+		app.umaskSet = true
+	}
+}
+
+// umaskCmd wrap cmd with umask shell shim.
+func (app *App) umaskCmd(cmd *exec.Cmd) (*exec.Cmd, error) {
+	path := cmd.Path
+	sh := "sh"
+	if app.executor == LocalExecutor {
+		var err error
+		sh, err = exec.LookPath("sh")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Path resolved against local PATH is meaningless for other backends.
+		path = cmd.Args[0]
+	}
+	wrapped := *cmd
+	wrapped.Path = sh
+	wrapped.Args = append([]string{"sh", "-c",
+		fmt.Sprintf(`umask %04o && exec "$@"`, app.umask), "sh", path},
+		cmd.Args[1:]...)
+	return &wrapped, nil
+}
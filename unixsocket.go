@@ -0,0 +1,152 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// WithUnixSocketClient bridge application stdin and stdout to the Unix
+// domain socket at path, which is connected at start: socket descriptor
+// is passed to the process directly, so data doesn't go through the Go
+// process. Stderr is handled as usual.
+func WithUnixSocketClient(path string) Option {
+	return func(app *App) {
+		app.unixSocket = path
+		app.unixServer = false
+	}
+}
+
+// WithUnixSocketServer create listening Unix domain socket at path
+// (removed once application exits), which allow out-of-process tools
+// to attach to the running application: every client connected receive
+// copy of stdout and stderr, and data sent by clients go to stdin.
+func WithUnixSocketServer(path string) Option {
+	return func(app *App) {
+		app.unixSocket = path
+		app.unixServer = true
+	}
+}
+
+// connectUnixSocket connect application stdin and stdout to socket
+// in client mode, passing socket descriptor directly.
+func (app *App) connectUnixSocket(stdin, stdout bool) error {
+	if app.unixSocket == "" || app.unixServer {
+		return nil
+	}
+	if stdin || stdout {
+		return errors.New("stdio is bridged to Unix socket, but reader or writer is passed")
+	}
+	conn, err := net.Dial("unix", app.unixSocket)
+	if err != nil {
+		return err
+	}
+	file, err := conn.(*net.UnixConn).File()
+	conn.Close()
+	if err != nil {
+		return err
+	}
+	app.closers = append(app.closers, file)
+	app.cmd.Stdin = file
+	app.cmd.Stdout = file
+	return nil
+}
+
+// unixSocketWriters start socket server, if configured, and return
+// writers broadcasting output to connected clients.
+func (app *App) unixSocketWriters() (io.Writer, io.Writer, error) {
+	if app.unixSocket == "" || !app.unixServer {
+		return nil, nil, nil
+	}
+	l, err := net.Listen("unix", app.unixSocket)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := &socketBridge{listener: l}
+	app.socketStdin = b.stdin()
+	// Listener close remove socket file.
+	app.closers = append(app.closers, b)
+	go b.accept()
+	return b, b, nil
+}
+
+// socketBridge broadcast output to all connected clients
+// and merge data received from them into single stdin stream.
+type socketBridge struct {
+	sync.Mutex
+	listener net.Listener
+	conns    []net.Conn
+	stdinW   *io.PipeWriter
+	stdinMu  sync.Mutex
+}
+
+func (b *socketBridge) stdin() io.Reader {
+	r, w := io.Pipe()
+	b.stdinW = w
+	return r
+}
+
+func (b *socketBridge) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.Lock()
+		b.conns = append(b.conns, conn)
+		b.Unlock()
+		go b.receive(conn)
+	}
+}
+
+func (b *socketBridge) receive(conn net.Conn) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			b.stdinMu.Lock()
+			_, werr := b.stdinW.Write(buf[:n])
+			b.stdinMu.Unlock()
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write send data to all clients, dropping the ones failed.
+func (b *socketBridge) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	alive := b.conns[:0]
+	for _, conn := range b.conns {
+		if _, err := conn.Write(p); err != nil {
+			conn.Close()
+			continue
+		}
+		alive = append(alive, conn)
+	}
+	b.conns = alive
+	return len(p), nil
+}
+
+// Close stop server and disconnect clients.
+func (b *socketBridge) Close() error {
+	err := b.listener.Close()
+	b.stdinW.Close()
+	b.Lock()
+	defer b.Unlock()
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+	b.conns = nil
+	if addr, ok := b.listener.Addr().(*net.UnixAddr); ok {
+		os.Remove(addr.Name)
+	}
+	return err
+}
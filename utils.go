@@ -2,14 +2,12 @@ package shell
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
 	"runtime"
-	"syscall"
 )
 
 // IsLinuxMacOSFreeBSD determine that running OS belong to Linux, BSD or macOS.
@@ -28,37 +26,7 @@ func CheckRunAsRoot() bool {
 
 // GetFreeSpace use syscall to find free space for path specified.
 func GetFreeSpace(path string) (uint64, error) {
-	const errMsg = "can't detect free space available on the system"
-	var space uint64
-	if IsLinuxMacOSFreeBSD() {
-		var stat syscall.Statfs_t
-		err := syscall.Statfs(path, &stat)
-		if err != nil {
-			return 0, err
-		}
-		// stat.Bavail type is differs on Linux and FreeBSD, so
-		// this check is valid, despite warning about non-negative UINT64.
-		// So, please, ignore linter warning here.
-		if stat.Bavail < 0 {
-			return 0, errors.New(errMsg)
-		}
-		// Available blocks * size per block = available space in bytes.
-		space = uint64(stat.Bavail) * uint64(stat.Bsize)
-	} else {
-		return 0, errors.New(errMsg)
-	}
-	/* else {
-		h := syscall.LoadLibrary("kernel32.dll")
-		c := h.MustFindProc("GetDiskFreeSpaceExW")
-		var freeBytes int64
-		_, _, err := c.Call(uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(path))),
-			uintptr(unsafe.Pointer(&freeBytes)), nil, nil)
-		if err != nil {
-			return 0, err
-		}
-		space = uint64(freeBytes)
-	}*/
-	return space, nil
+	return freeSpace(path)
 }
 
 // CopyFile copy regular file.
@@ -0,0 +1,187 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a semantic version of the tool, see ProbeVersion.
+type Version struct {
+	Major, Minor, Patch int
+	// Pre is a pre-release suffix without leading dash ("rc1" and so on).
+	Pre string
+}
+
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.]+))?`)
+
+// ParseVersion extract the first version in the form
+// "major.minor[.patch][-pre]" found in the string.
+func ParseVersion(s string) (Version, error) {
+	m := versionRe.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("version not found in %q", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	v.Pre = m[4]
+	return v, nil
+}
+
+// String return version in the form "major.minor.patch[-pre]".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare return -1, 0 or 1, if v is less, equal or greater than w;
+// pre-release version precede release one.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, w.Pre)
+}
+
+// comparePre compare pre-release suffixes by dot-separated identifiers,
+// as semantic versioning does: numeric ones are compared as numbers and
+// precede alphanumeric ones, compared lexically, while suffix with less
+// identifiers precede longer one. Since tools rarely separate number
+// ("rc10"), alphanumeric identifiers with the same prefix are compared
+// by trailing number, so "rc2" precede "rc10".
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := comparePreIdent(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func comparePreIdent(a, b string) int {
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return compareInts(an, bn)
+	case aerr == nil:
+		return -1
+	case berr == nil:
+		return 1
+	}
+	ap, anum := splitTrailingNumber(a)
+	bp, bnum := splitTrailingNumber(b)
+	if ap == bp && anum >= 0 && bnum >= 0 {
+		return compareInts(anum, bnum)
+	}
+	return strings.Compare(a, b)
+}
+
+// splitTrailingNumber split identifier into prefix and trailing
+// number, which is -1, if identifier doesn't end with digits.
+func splitTrailingNumber(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	n, err := strconv.Atoi(s[i:])
+	if err != nil {
+		return s, -1
+	}
+	return s[:i], n
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast report, whether v is equal or greater than w.
+func (v Version) AtLeast(w Version) bool {
+	return v.Compare(w) >= 0
+}
+
+// versionFlags are tried by ProbeVersion in the order.
+var versionFlags = [][]string{{"--version"}, {"-version"}, {"-V"}, {"version"}, {"-v"}}
+
+// probeTimeout limit every version probe.
+const probeTimeout = 5 * time.Second
+
+// ProbeVersion run tool to find out its version: with args specified,
+// if any, otherwise trying common version flags (--version, -version,
+// -V, version, -v) till output (stdout and stderr, since some tools
+// print version there) contain version.
+func ProbeVersion(name string, args ...string) (Version, error) {
+	variants := versionFlags
+	if len(args) > 0 {
+		variants = [][]string{args}
+	}
+	var lastErr error
+	for _, flags := range variants {
+		out, err := probeOutput(name, flags)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		v, err := ParseVersion(out)
+		if err == nil {
+			return v, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("version not found")
+	}
+	return Version{}, fmt.Errorf("can't probe version of %q: %v", name, lastErr)
+}
+
+// probeOutput run tool with stdin closed, returning its output.
+func probeOutput(name string, args []string) (string, error) {
+	app := NewApp(name, args...).With(WithMaxCaptureBytes(64 * 1024))
+	var out bytes.Buffer
+	ch, err := app.Start(nil, &out, &out)
+	if err != nil {
+		return "", err
+	}
+	var st ExitCodeOrError
+	select {
+	case st = <-ch:
+	case <-time.After(probeTimeout):
+		app.Kill()
+		return "", errors.New("version probe timed out")
+	}
+	if st.Error != nil {
+		return "", st.Error
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package shell
+
+import "testing"
+
+func TestVersionCompare(t *testing.T) {
+	// Every version precede the next one.
+	ordered := []string{"1.0.0-1", "1.0.0-2", "1.0.0-10", "1.0.0-alpha", "1.0.0-alpha.1",
+		"1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc1",
+		"1.0.0-rc2", "1.0.0-rc10", "1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+	for i := 0; i < len(ordered)-1; i++ {
+		v, err := ParseVersion(ordered[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := ParseVersion(ordered[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Compare(w) != -1 || w.Compare(v) != 1 || v.Compare(v) != 0 {
+			t.Fatalf("%s doesn't precede %s", v, w)
+		}
+	}
+}
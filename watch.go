@@ -0,0 +1,270 @@
+package shell
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WatchMode define, what Watcher do with application running,
+// when files changed.
+type WatchMode int
+
+const (
+	// WatchRestart kill application running and start new one.
+	WatchRestart WatchMode = iota
+	// WatchQueue let application running to finish and start new
+	// one after that; changes happened meanwhile cause single run.
+	WatchQueue
+)
+
+// DefaultWatchPollInterval is used, if Watcher.PollInterval is zero
+// and file change notifications are not available.
+const DefaultWatchPollInterval = 250 * time.Millisecond
+
+// Watcher run copy of application (see App.Clone) once started
+// and every time files watched are changed (created, removed,
+// modified or changed mode), once no more changes happened within
+// debounce, as a building block of development tools. Changes are
+// detected comparing modification time, size and mode of files, which
+// are rescanned, once Linux inotify report change, either periodically
+// on other platforms (or if inotify failed). Package fsnotify is not
+// used, to keep the package free of dependencies besides golang.org/x.
+// Directories are watched recursively. Configure fields before Start.
+type Watcher struct {
+	// Mode is WatchRestart by default.
+	Mode WatchMode
+	// PollInterval is DefaultWatchPollInterval, if zero. Set it
+	// explicitly to poll along with inotify as well, for instance,
+	// on network file systems, which don't report changes.
+	PollInterval time.Duration
+
+	app      *App
+	paths    []string
+	debounce time.Duration
+	mutex    sync.Mutex
+	last     ExitCodeOrError
+	results  chan ExitCodeOrError
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Watch create watcher, which rerun app, when files or
+// directories listed in paths are changed.
+func Watch(app *App, paths []string, debounce time.Duration) *Watcher {
+	return &Watcher{app: app, paths: append([]string{}, paths...),
+		debounce: debounce, results: make(chan ExitCodeOrError, 1)}
+}
+
+// Start watch files in background and run application immediately.
+func (w *Watcher) Start() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.stop != nil {
+		return errors.New("watcher is started already")
+	}
+	if len(w.paths) == 0 {
+		return errors.New("no paths to watch")
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.loop()
+	return nil
+}
+
+// Stop terminate watching, killing application running, and close
+// channel returned by Results. Watcher can't be restarted.
+func (w *Watcher) Stop() {
+	w.mutex.Lock()
+	if w.stop == nil {
+		w.mutex.Unlock()
+		return
+	}
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+	done := w.done
+	w.mutex.Unlock()
+	<-done
+}
+
+// LastResult return exit status of the latest run finished.
+func (w *Watcher) LastResult() ExitCodeOrError {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.last
+}
+
+// Results return channel, which receive exit status of every run
+// finished (killed by restart included). Reader, which is late,
+// get only the latest one.
+func (w *Watcher) Results() <-chan ExitCodeOrError {
+	return w.results
+}
+
+// fileNotifier wake watcher up, once entries of directories
+// watched are changed.
+type fileNotifier interface {
+	Events() <-chan struct{}
+	Watch(dir string) error
+	Close() error
+}
+
+// fileState is a part of file metadata, which change is detected.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+// scan collect state of all files watched, registering
+// directories found with notifier, if any.
+func (w *Watcher) scan(notifier fileNotifier) map[string]fileState {
+	files := make(map[string]fileState)
+	for _, path := range w.paths {
+		if notifier != nil {
+			// Parent report creation and removal of path itself.
+			notifier.Watch(filepath.Dir(path))
+		}
+		filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable entries, missing ones are detected,
+				// once they appear.
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if notifier != nil && d.IsDir() {
+				notifier.Watch(name)
+			}
+			files[name] = fileState{modTime: info.ModTime(),
+				size: info.Size(), mode: info.Mode()}
+			return nil
+		})
+	}
+	return files
+}
+
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, st := range a {
+		if other, ok := b[name]; !ok || other != st {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Watcher) loop() {
+	defer close(w.done)
+	defer close(w.results)
+	notifier, err := newFileNotifier()
+	var events <-chan struct{}
+	if err == nil {
+		defer notifier.Close()
+		events = notifier.Events()
+	}
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	poll := func() {
+		interval := w.PollInterval
+		if interval <= 0 {
+			interval = DefaultWatchPollInterval
+		}
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+	if notifier == nil || w.PollInterval > 0 {
+		poll()
+	}
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	files := w.scan(notifier)
+	var running *App
+	var ch chan ExitCodeOrError
+	// Run immediately, since no change is seen yet.
+	pending := true
+	var changed time.Time
+	for {
+		var settled <-chan time.Time
+		if pending && (running == nil || w.Mode == WatchRestart) {
+			if left := w.debounce - time.Since(changed); left > 0 {
+				settled = time.After(left)
+			} else {
+				if running != nil {
+					running.Kill()
+					w.record(<-ch)
+				}
+				running, ch = w.run()
+				pending = false
+			}
+		}
+		rescan := false
+		select {
+		case st := <-ch:
+			w.record(st)
+			running, ch = nil, nil
+		case <-settled:
+		case <-tick:
+			rescan = true
+		case _, ok := <-events:
+			if !ok {
+				// Notifications failed, switch to polling.
+				events, notifier = nil, nil
+				if ticker == nil {
+					poll()
+				}
+			}
+			rescan = true
+		case <-w.stop:
+			if running != nil {
+				running.Kill()
+				<-ch
+			}
+			return
+		}
+		if rescan {
+			current := w.scan(notifier)
+			if !sameFiles(files, current) {
+				files = current
+				changed = time.Now()
+				pending = true
+			}
+		}
+	}
+}
+
+// run start copy of application, record failure to start.
+func (w *Watcher) run() (*App, chan ExitCodeOrError) {
+	app := w.app.Clone()
+	ch, err := app.Start(nil, nil, nil)
+	if err != nil {
+		w.record(ExitCodeOrError{0, err})
+		return nil, nil
+	}
+	return app, ch
+}
+
+// record keep exit status of the run finished.
+func (w *Watcher) record(st ExitCodeOrError) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.last = st
+	// Replace result not received yet with the latest one.
+	select {
+	case <-w.results:
+	default:
+	}
+	w.results <- st
+}
@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask select changes of directory entries reported.
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY |
+	unix.IN_ATTRIB | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// inotifyNotifier report changes with Linux inotify.
+type inotifyNotifier struct {
+	// fd is kept apart, since File.Fd switch it to blocking mode.
+	fd     int
+	file   *os.File
+	events chan struct{}
+}
+
+func newFileNotifier() (fileNotifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// Non-blocking descriptor is served by runtime poller,
+	// so Close unblock Read.
+	n := &inotifyNotifier{fd: fd, file: os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan struct{}, 1)}
+	go n.read()
+	return n, nil
+}
+
+// read wake watcher up on every batch of events. Events themselves
+// are not decoded, since watcher rescan files anyway.
+func (n *inotifyNotifier) read() {
+	defer close(n.events)
+	buf := make([]byte, 64*1024)
+	for {
+		if _, err := n.file.Read(buf); err != nil {
+			return
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *inotifyNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+// Watch add directory to watch, which is a no-op for
+// directory already watched. Watch is removed by kernel,
+// once directory is deleted.
+func (n *inotifyNotifier) Watch(dir string) error {
+	_, err := unix.InotifyAddWatch(n.fd, dir, inotifyMask)
+	return err
+}
+
+func (n *inotifyNotifier) Close() error {
+	return n.file.Close()
+}
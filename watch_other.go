@@ -0,0 +1,9 @@
+//go:build !linux
+
+package shell
+
+import "errors"
+
+func newFileNotifier() (fileNotifier, error) {
+	return nil, errors.New("file change notifications are not supported")
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitResult wait for the next run of watcher finished.
+func waitResult(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case <-w.Results():
+	case <-time.After(5 * time.Second):
+		t.Fatal("application is not run")
+	}
+}
+
+func testWatchRerun(t *testing.T, poll time.Duration) {
+	dir := t.TempDir()
+	runs := t.TempDir()
+	w := Watch(countingApp(runs, "true"), []string{dir}, 50*time.Millisecond)
+	w.PollInterval = poll
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+	waitResult(t, w)
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	waitResult(t, w)
+	// File in directory created after start is watched as well.
+	if err := os.WriteFile(filepath.Join(sub, "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitResult(t, w)
+	if n := countRuns(t, runs); n != 3 {
+		t.Fatalf("command executed %d times, expected 3", n)
+	}
+}
+
+func TestWatchRerun(t *testing.T) {
+	testWatchRerun(t, 0)
+}
+
+func TestWatchRerunPolling(t *testing.T) {
+	testWatchRerun(t, 20*time.Millisecond)
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir := t.TempDir()
+	runs := t.TempDir()
+	w := Watch(countingApp(runs, "true"), []string{dir}, 300*time.Millisecond)
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+	waitResult(t, w)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(filepath.Join(dir, "file"), []byte{byte(i)}, 0644)
+		time.Sleep(20 * time.Millisecond)
+	}
+	waitResult(t, w)
+	time.Sleep(400 * time.Millisecond)
+	if n := countRuns(t, runs); n != 2 {
+		t.Fatalf("command executed %d times, expected twice", n)
+	}
+}
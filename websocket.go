@@ -0,0 +1,186 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+)
+
+// WebSocketMessage is a JSON frame exchanged between
+// browser terminal and application attached to WebSocket.
+// Client send "stdin" (Data), "eof" (close stdin), "resize"
+// (Cols, Rows) and "close" (disconnect) messages.
+// Server send "stdout", "stderr" (Data), "exit" (Code)
+// and "error" (Message) ones.
+type WebSocketMessage struct {
+	Type    string `json:"type"`
+	Data    []byte `json:"data,omitempty"`
+	Cols    int    `json:"cols,omitempty"`
+	Rows    int    `json:"rows,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ResizeFunc change terminal window size of the application,
+// when client report resize event. Used with applications
+// running in pseudo-terminal.
+type ResizeFunc func(app *App, cols, rows int) error
+
+// AttachWebSocket connect WebSocket to running application started
+// with WithBroadcast and serve it till application exits or client
+// disconnects, in the latter case application keep running. Client
+// receive output kept in backlog first, then output as it arrives.
+// Data client send go to the pseudo-terminal, if application is started
+// by StartPTY, or to application stdin, if FeedStdin is used, otherwise
+// it's dropped. Any number of clients can be attached simultaneously.
+// If resize is nil, resize messages are ignored.
+func AttachWebSocket(ws *websocket.Conn, app *App, resize ResizeFunc) ExitCodeOrError {
+	defer ws.Close()
+	app.mutex.Lock()
+	b, master, feed := app.broadcaster, app.ptyMaster, app.stdinFeedW
+	app.mutex.Unlock()
+	if b == nil || !app.isStarted() {
+		err := errors.New("App is not started with broadcast")
+		sendWebSocketError(ws, err)
+		return ExitCodeOrError{0, err}
+	}
+	var stdin io.WriteCloser
+	if master != nil {
+		stdin = &ptyStdin{master: master}
+	} else if feed != nil {
+		stdin = feed
+	}
+	detach, done := b.attach(&webSocketWriter{ws: ws, typ: "stdout"},
+		&webSocketWriter{ws: ws, typ: "stderr"})
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		receiveWebSocket(ws, app, stdin, resize)
+	}()
+	select {
+	case <-done:
+	case <-gone:
+		detach()
+		return *app.ExitCodeOrError()
+	}
+	// Output is over, so status is available.
+	st := app.wait()
+	sendWebSocketStatus(ws, st)
+	return st
+}
+
+// RunWebSocket start application with stdio connected to WebSocket
+// and serve it till application exits or client disconnects,
+// in the latter case application is killed. If resize is nil,
+// resize messages are ignored.
+func RunWebSocket(ws *websocket.Conn, app *App, resize ResizeFunc) ExitCodeOrError {
+	defer ws.Close()
+	stdin, stdinWriter := io.Pipe()
+	// Pipe reach EOF only once client disconnects,
+	// so it mustn't delay application exit.
+	app.closableStdin = true
+	ch, err := app.Start(stdin, &webSocketWriter{ws: ws, typ: "stdout"},
+		&webSocketWriter{ws: ws, typ: "stderr"})
+	if err != nil {
+		sendWebSocketError(ws, err)
+		return ExitCodeOrError{0, err}
+	}
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		defer stdinWriter.Close()
+		receiveWebSocket(ws, app, stdinWriter, resize)
+	}()
+	var st ExitCodeOrError
+	select {
+	case st = <-ch:
+	case <-gone:
+		app.Kill()
+		return *app.ExitCodeOrError()
+	}
+	sendWebSocketStatus(ws, st)
+	return st
+}
+
+// NewWebSocketHandler return HTTP handler, which on every
+// WebSocket connection start a copy of application (see App.Clone)
+// connected to it via RunWebSocket.
+func NewWebSocketHandler(app *App, resize ResizeFunc) http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		RunWebSocket(ws, app.Clone(), resize)
+	})
+}
+
+// receiveWebSocket handle client messages till it disconnects.
+// Stdin data is dropped, if stdin is nil.
+func receiveWebSocket(ws *websocket.Conn, app *App, stdin io.WriteCloser, resize ResizeFunc) {
+	for {
+		var msg WebSocketMessage
+		err := websocket.JSON.Receive(ws, &msg)
+		if err != nil {
+			return
+		}
+		switch msg.Type {
+		case "stdin":
+			if stdin != nil {
+				stdin.Write(msg.Data)
+			}
+		case "eof":
+			if stdin != nil {
+				stdin.Close()
+			}
+		case "resize":
+			if resize != nil {
+				resize(app, msg.Cols, msg.Rows)
+			}
+		case "close":
+			return
+		}
+	}
+}
+
+func sendWebSocketError(ws *websocket.Conn, err error) {
+	websocket.JSON.Send(ws, &WebSocketMessage{Type: "error",
+		Message: err.Error()})
+}
+
+func sendWebSocketStatus(ws *websocket.Conn, st ExitCodeOrError) {
+	if st.Error != nil {
+		sendWebSocketError(ws, st.Error)
+	} else {
+		websocket.JSON.Send(ws, &WebSocketMessage{Type: "exit",
+			Code: st.ExitCode})
+	}
+}
+
+// ptyStdin write client data to pseudo-terminal, which is shared
+// by all clients, so on close end-of-file character is sent
+// instead of closing master side.
+type ptyStdin struct {
+	master io.Writer
+}
+
+func (w *ptyStdin) Write(p []byte) (int, error) {
+	return w.master.Write(p)
+}
+
+func (w *ptyStdin) Close() error {
+	_, err := w.master.Write([]byte{4})
+	return err
+}
+
+// webSocketWriter send every chunk written in separate message.
+type webSocketWriter struct {
+	ws  *websocket.Conn
+	typ string
+}
+
+func (w *webSocketWriter) Write(p []byte) (int, error) {
+	err := websocket.JSON.Send(w.ws, &WebSocketMessage{Type: w.typ, Data: p})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
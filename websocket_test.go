@@ -0,0 +1,95 @@
+//go:build !windows
+
+package shell
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// dialWebSocket connect client to handler served by test server.
+func dialWebSocket(t *testing.T, h websocket.Handler) *websocket.Conn {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	ws, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// receiveOutput collect stdout messages till exit or error one.
+func receiveOutput(t *testing.T, ws *websocket.Conn) (string, WebSocketMessage) {
+	var out strings.Builder
+	for {
+		var msg WebSocketMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		switch msg.Type {
+		case "stdout":
+			out.Write(msg.Data)
+		case "exit", "error":
+			return out.String(), msg
+		}
+	}
+}
+
+func TestAttachWebSocket(t *testing.T) {
+	app := NewApp("cat").With(WithBroadcast(1024))
+	feed := app.FeedStdin()
+	if _, err := app.Start(nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	feed <- []byte("backlog\n")
+	// Wait for output to get into backlog.
+	for b := app.broadcaster; ; time.Sleep(10 * time.Millisecond) {
+		b.Lock()
+		n := len(b.out)
+		b.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+	ws := dialWebSocket(t, func(ws *websocket.Conn) {
+		AttachWebSocket(ws, app, nil)
+	})
+	for _, msg := range []WebSocketMessage{{Type: "stdin", Data: []byte("data\n")}, {Type: "eof"}} {
+		if err := websocket.JSON.Send(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, msg := receiveOutput(t, ws)
+	if out != "backlog\ndata\n" || msg.Type != "exit" || msg.Code != 0 {
+		t.Fatalf("unexpected output %q and message %+v", out, msg)
+	}
+}
+
+func TestAttachWebSocketNotStarted(t *testing.T) {
+	app := NewApp("cat").With(WithBroadcast(0))
+	ws := dialWebSocket(t, func(ws *websocket.Conn) {
+		AttachWebSocket(ws, app, nil)
+	})
+	if _, msg := receiveOutput(t, ws); msg.Type != "error" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
+
+func TestRunWebSocket(t *testing.T) {
+	ws := dialWebSocket(t, NewWebSocketHandler(NewApp("cat"), nil).(websocket.Handler))
+	for _, msg := range []WebSocketMessage{{Type: "stdin", Data: []byte("data\n")}, {Type: "eof"}} {
+		if err := websocket.JSON.Send(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, msg := receiveOutput(t, ws)
+	if out != "data\n" || msg.Type != "exit" || msg.Code != 0 {
+		t.Fatalf("unexpected output %q and message %+v", out, msg)
+	}
+}